@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+
+	"github.com/rl404/image-randomizer/internal/domain/user/entity"
+	userDB "github.com/rl404/image-randomizer/internal/domain/user/repository/db"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+func adminCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Manage admin users",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "grant [username]",
+		Short: "Grant admin role to user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return adminSetRole(args[0], entity.RoleAdmin)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "revoke [username]",
+		Short: "Revoke admin role from user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return adminSetRole(args[0], entity.RoleUser)
+		},
+	})
+
+	return cmd
+}
+
+func newUserDB() (*userDB.DB, func(), error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := newDB(cfg.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmp, _ := db.DB()
+
+	return userDB.New(db), func() { tmp.Close() }, nil
+}
+
+func adminSetRole(username string, role entity.Role) error {
+	user, closeDB, err := newUserDB()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	u, _, err := user.GetByUsername(context.Background(), username)
+	if err != nil {
+		return err
+	}
+
+	u.Role = role
+
+	if _, err := user.UpdateRole(context.Background(), *u); err != nil {
+		return err
+	}
+
+	utils.Info("%s role updated to %s", u.Username, role)
+	return nil
+}
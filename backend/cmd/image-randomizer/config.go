@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/rl404/image-randomizer/internal/errors"
 	"github.com/rl404/image-randomizer/internal/utils"
 	"github.com/rl404/image-randomizer/pkg/cache"
+	"github.com/rl404/image-randomizer/pkg/pubsub"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -19,12 +21,23 @@ import (
 )
 
 type config struct {
-	App      appConfig      `envconfig:"APP"`
-	Cache    cacheConfig    `envconfig:"CACHE"`
-	DB       dbConfig       `envconfig:"DB"`
-	JWT      jwtConfig      `envconfig:"JWT"`
-	Log      logConfig      `envconfig:"LOG"`
-	Newrelic newrelicConfig `envconfig:"NEWRELIC"`
+	App       appConfig       `envconfig:"APP"`
+	Cache     cacheConfig     `envconfig:"CACHE"`
+	DB        dbConfig        `envconfig:"DB"`
+	JWT       jwtConfig       `envconfig:"JWT"`
+	Log       logConfig       `envconfig:"LOG"`
+	Newrelic  newrelicConfig  `envconfig:"NEWRELIC"`
+	PubSub    pubsubConfig    `envconfig:"PUBSUB"`
+	Password  passwordConfig  `envconfig:"PASSWORD"`
+	Login     loginConfig     `envconfig:"LOGIN"`
+	OIDC      oidcConfig      `envconfig:"OIDC"`
+	TOTP      totpConfig      `envconfig:"TOTP"`
+	Register  registerConfig  `envconfig:"REGISTER"`
+	Cookie    cookieConfig    `envconfig:"COOKIE"`
+	SignedURL signedURLConfig `envconfig:"SIGNED_URL"`
+	RateLimit rateLimitConfig `envconfig:"RATE_LIMIT"`
+	Quota     quotaConfig     `envconfig:"QUOTA"`
+	Analytics analyticsConfig `envconfig:"ANALYTICS"`
 }
 
 type appConfig struct {
@@ -42,6 +55,12 @@ type cacheConfig struct {
 	Time     time.Duration `envconfig:"TIME" default:"60m" validate:"required,gt=0"`
 }
 
+type pubsubConfig struct {
+	Dialect  string `envconfig:"DIALECT" validate:"required,oneof=nopubsub redis" mod:"default=nopubsub,no_space,lcase"`
+	Address  string `envconfig:"ADDRESS"`
+	Password string `envconfig:"PASSWORD"`
+}
+
 type dbConfig struct {
 	Address         string        `envconfig:"ADDRESS" validate:"required" mod:"default=localhost:5432,no_space"`
 	Name            string        `envconfig:"NAME" validate:"required" mod:"default=image-randomizer"`
@@ -53,10 +72,92 @@ type dbConfig struct {
 }
 
 type jwtConfig struct {
-	AccessSecret   string        `envconfig:"ACCESS_SECRET" validate:"required"`
-	AccessExpired  time.Duration `envconfig:"ACCESS_EXPIRED" default:"15m" validate:"required,gt=0"`
-	RefreshSecret  string        `envconfig:"REFRESH_SECRET" validate:"required"`
-	RefreshExpired time.Duration `envconfig:"REFRESH_EXPIRED" default:"168h" validate:"required,gt=0"`
+	AccessSecret    string        `envconfig:"ACCESS_SECRET" validate:"required"`
+	AccessExpired   time.Duration `envconfig:"ACCESS_EXPIRED" default:"15m" validate:"required,gt=0"`
+	RefreshSecret   string        `envconfig:"REFRESH_SECRET" validate:"required"`
+	RefreshExpired  time.Duration `envconfig:"REFRESH_EXPIRED" default:"168h" validate:"required,gt=0"`
+	RefreshGrace    time.Duration `envconfig:"REFRESH_GRACE" default:"10s" validate:"gte=0"`
+	CleanupInterval time.Duration `envconfig:"CLEANUP_INTERVAL" default:"1h" validate:"required,gt=0"`
+	PrivateKeyFile  string        `envconfig:"PRIVATE_KEY_FILE"`
+	PublicKeyFiles  []string      `envconfig:"PUBLIC_KEY_FILES"`
+	// Deprecated: token in query string leaks to logs and referrers.
+	AllowQuery bool `envconfig:"ALLOW_QUERY" default:"false"`
+}
+
+type passwordConfig struct {
+	Pepper       string `envconfig:"PEPPER"`
+	LegacyPepper string `envconfig:"LEGACY_PEPPER" default:"yagoobestgirl"`
+	Memory       uint32 `envconfig:"MEMORY" default:"65536" validate:"required,gt=0"`
+	Iterations   uint32 `envconfig:"ITERATIONS" default:"3" validate:"required,gt=0"`
+	Parallelism  uint8  `envconfig:"PARALLELISM" default:"2" validate:"required,gt=0"`
+}
+
+type loginConfig struct {
+	FreeFailures        int           `envconfig:"FREE_FAILURES" default:"3" validate:"gte=0"`
+	UsernameMaxFailures int           `envconfig:"USERNAME_MAX_FAILURES" default:"10" validate:"required,gt=0"`
+	IPMaxFailures       int           `envconfig:"IP_MAX_FAILURES" default:"50" validate:"required,gt=0"`
+	BackoffBase         time.Duration `envconfig:"BACKOFF_BASE" default:"1s" validate:"required,gt=0"`
+	BackoffMax          time.Duration `envconfig:"BACKOFF_MAX" default:"1m" validate:"required,gt=0"`
+	Lockout             time.Duration `envconfig:"LOCKOUT" default:"15m" validate:"required,gt=0"`
+	Window              time.Duration `envconfig:"WINDOW" default:"1h" validate:"required,gt=0"`
+}
+
+type oidcConfig struct {
+	Issuer       string        `envconfig:"ISSUER"`
+	ClientID     string        `envconfig:"CLIENT_ID" validate:"required_with=Issuer"`
+	ClientSecret string        `envconfig:"CLIENT_SECRET"`
+	RedirectURL  string        `envconfig:"REDIRECT_URL" validate:"required_with=Issuer"`
+	Scopes       []string      `envconfig:"SCOPES" default:"openid,profile,email"`
+	AutoRegister bool          `envconfig:"AUTO_REGISTER" default:"false"`
+	StateExpired time.Duration `envconfig:"STATE_EXPIRED" default:"10m" validate:"required,gt=0"`
+}
+
+type totpConfig struct {
+	Issuer string `envconfig:"ISSUER" default:"image-randomizer" validate:"required"`
+}
+
+type registerConfig struct {
+	Mode              string   `envconfig:"MODE" validate:"required,oneof=open invite closed" mod:"default=open,no_space,lcase"`
+	ReservedUsernames []string `envconfig:"RESERVED_USERNAMES" default:"admin,administrator,root,system,api,swagger,images,image,user,users,login,logout,register,token,sessions,oidc,list,hotlink,usage,stats,ping,static,assets,help,support"`
+	// Proof-of-work leading zero bits. Set 0 to disable.
+	ChallengeDifficulty int           `envconfig:"CHALLENGE_DIFFICULTY" default:"16" validate:"gte=0,lte=32"`
+	ChallengeSecret     string        `envconfig:"CHALLENGE_SECRET" validate:"required_unless=ChallengeDifficulty 0"`
+	ChallengeExpired    time.Duration `envconfig:"CHALLENGE_EXPIRED" default:"5m" validate:"required,gt=0"`
+}
+
+type cookieConfig struct {
+	Enabled  bool   `envconfig:"ENABLED" default:"false"`
+	Domain   string `envconfig:"DOMAIN"`
+	Secure   bool   `envconfig:"SECURE" default:"true"`
+	SameSite string `envconfig:"SAME_SITE" validate:"required,oneof=lax strict none" mod:"default=lax,no_space,lcase"`
+}
+
+type signedURLConfig struct {
+	// Must be different from jwt secrets.
+	Secret     string        `envconfig:"SECRET" validate:"required"`
+	Expired    time.Duration `envconfig:"EXPIRED" default:"15m" validate:"required,gt=0"`
+	MaxExpired time.Duration `envconfig:"MAX_EXPIRED" default:"24h" validate:"required,gtefield=Expired"`
+}
+
+type rateLimitConfig struct {
+	// Random image requests allowed per period. Set 0 to disable.
+	IPLimit        int           `envconfig:"IP_LIMIT" default:"120" validate:"gte=0"`
+	IPPeriod       time.Duration `envconfig:"IP_PERIOD" default:"1m" validate:"required,gt=0"`
+	UsernameLimit  int           `envconfig:"USERNAME_LIMIT" default:"600" validate:"gte=0"`
+	UsernamePeriod time.Duration `envconfig:"USERNAME_PERIOD" default:"1m" validate:"required,gt=0"`
+}
+
+type quotaConfig struct {
+	// Default quota for each user. Set 0 for unlimited.
+	ImageLimit   int64 `envconfig:"IMAGE_LIMIT" default:"0" validate:"gte=0"`
+	MonthlyBytes int64 `envconfig:"MONTHLY_BYTES" default:"0" validate:"gte=0"`
+}
+
+type analyticsConfig struct {
+	// Views and hotlink block counters are saved
+	// when the batch is full or every interval.
+	BatchSize     int           `envconfig:"BATCH_SIZE" default:"500" validate:"required,gt=0"`
+	FlushInterval time.Duration `envconfig:"FLUSH_INTERVAL" default:"5s" validate:"required,gt=0"`
 }
 
 type logConfig struct {
@@ -79,6 +180,11 @@ var cacheType = map[string]cache.CacheType{
 	"inmemory": cache.InMemory,
 }
 
+var pubsubType = map[string]pubsub.PubsubType{
+	"nopubsub": pubsub.NOP,
+	"redis":    pubsub.Redis,
+}
+
 func getConfig() (*config, error) {
 	var cfg config
 
@@ -100,12 +206,28 @@ func getConfig() (*config, error) {
 		return nil, err
 	}
 
+	// Signed url must not be signed with jwt secret.
+	if cfg.SignedURL.Secret == cfg.JWT.AccessSecret || cfg.SignedURL.Secret == cfg.JWT.RefreshSecret {
+		return nil, errors.ErrNEField("IR_SIGNED_URL_SECRET", "jwt secret")
+	}
+
 	// Init global log.
 	utils.InitLog(cfg.Log.Level, cfg.Log.JSON, cfg.Log.Color)
 
 	return &cfg, nil
 }
 
+func getSameSite(sameSite string) http.SameSite {
+	switch sameSite {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
 func newDB(cfg dbConfig) (*gorm.DB, error) {
 	// Split host and port.
 	split := strings.Split(cfg.Address, ":")
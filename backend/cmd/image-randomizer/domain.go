@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	domainpolicyDB "github.com/rl404/image-randomizer/internal/domain/domainpolicy/repository/db"
+	"github.com/rl404/image-randomizer/internal/domain/image/entity"
+	imageDB "github.com/rl404/image-randomizer/internal/domain/image/repository/db"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// Images scanned per query.
+const rescanBatch = 1000
+
+func domainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "domain",
+		Short: "Manage image domain policy",
+	}
+
+	var del bool
+
+	rescanCmd := &cobra.Command{
+		Use:   "rescan",
+		Short: "Re-scan existing images against domain policy",
+		RunE: func(*cobra.Command, []string) error {
+			return domainRescan(del)
+		},
+	}
+	rescanCmd.Flags().BoolVarP(&del, "delete", "d", false, "delete images not allowed by the policy")
+
+	cmd.AddCommand(rescanCmd)
+
+	return cmd
+}
+
+func domainRescan(del bool) error {
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+
+	db, err := newDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	tmp, _ := db.DB()
+	defer tmp.Close()
+
+	ctx := context.Background()
+	image := imageDB.New(db)
+
+	policy, _, err := domainpolicyDB.New(db).GetPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	var afterID int64
+	var scanned, blocked int
+	for {
+		images, _, err := image.GetAll(ctx, afterID, rescanBatch)
+		if err != nil {
+			return err
+		}
+
+		if len(images) == 0 {
+			break
+		}
+
+		for _, img := range images {
+			afterID = img.ID
+			scanned++
+
+			if policy.IsAllowed(img.Image) {
+				continue
+			}
+
+			blocked++
+			fmt.Printf("%d\t%d\t%s\n", img.ID, img.UserID, img.Image)
+
+			if !del {
+				continue
+			}
+
+			if _, err := image.Delete(ctx, entity.Image{
+				ID:     img.ID,
+				UserID: img.UserID,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if del {
+		utils.Info("scanned %d images, deleted %d images", scanned, blocked)
+		return nil
+	}
+
+	utils.Info("scanned %d images, found %d blocked images", scanned, blocked)
+	return nil
+}
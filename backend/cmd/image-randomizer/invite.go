@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/invite/entity"
+	inviteDB "github.com/rl404/image-randomizer/internal/domain/invite/repository/db"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+func inviteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "invite",
+		Short: "Manage registration invite codes",
+	}
+
+	var maxUses int
+	var expired time.Duration
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create invite code",
+		RunE: func(*cobra.Command, []string) error {
+			return inviteCreate(maxUses, expired)
+		},
+	}
+	createCmd.Flags().IntVarP(&maxUses, "uses", "u", 1, "max uses")
+	createCmd.Flags().DurationVarP(&expired, "expired", "e", 7*24*time.Hour, "expiration duration, 0 for no expiration")
+
+	cmd.AddCommand(createCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List invite codes",
+		RunE: func(*cobra.Command, []string) error {
+			return inviteList()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "delete [id]",
+		Short: "Delete invite code",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			return inviteDelete(id)
+		},
+	})
+
+	return cmd
+}
+
+func newInviteDB() (*inviteDB.DB, func(), error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := newDB(cfg.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmp, _ := db.DB()
+
+	return inviteDB.New(db), func() { tmp.Close() }, nil
+}
+
+func inviteCreate(maxUses int, expired time.Duration) error {
+	invite, closeDB, err := newInviteDB()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	data := entity.Invite{MaxUses: maxUses}
+	if expired > 0 {
+		expiredAt := time.Now().Add(expired)
+		data.ExpiredAt = &expiredAt
+	}
+
+	inv, _, err := invite.Create(context.Background(), data)
+	if err != nil {
+		return err
+	}
+
+	utils.Info("invite created")
+	fmt.Println(inv.Code)
+
+	return nil
+}
+
+func inviteList() error {
+	invite, closeDB, err := newInviteDB()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	invites, _, err := invite.GetAll(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, inv := range invites {
+		expiredAt := "never"
+		if inv.ExpiredAt != nil {
+			expiredAt = inv.ExpiredAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%d\t%s\t%d/%d\t%s\n", inv.ID, inv.Code, inv.Uses, inv.MaxUses, expiredAt)
+	}
+
+	return nil
+}
+
+func inviteDelete(id int64) error {
+	invite, closeDB, err := newInviteDB()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if _, err := invite.Delete(context.Background(), id); err != nil {
+		return err
+	}
+
+	utils.Info("invite deleted")
+	return nil
+}
@@ -32,6 +32,11 @@ func main() {
 		},
 	})
 
+	cmd.AddCommand(inviteCmd())
+	cmd.AddCommand(adminCmd())
+	cmd.AddCommand(domainCmd())
+	cmd.AddCommand(statsCmd())
+
 	if err := cmd.Execute(); err != nil {
 		utils.Fatal(err.Error())
 	}
@@ -1,8 +1,17 @@
 package main
 
 import (
+	apikeyDB "github.com/rl404/image-randomizer/internal/domain/apikey/repository/db"
+	domainpolicyDB "github.com/rl404/image-randomizer/internal/domain/domainpolicy/repository/db"
+	hotlinkDB "github.com/rl404/image-randomizer/internal/domain/hotlink/repository/db"
 	imageDB "github.com/rl404/image-randomizer/internal/domain/image/repository/db"
+	inviteDB "github.com/rl404/image-randomizer/internal/domain/invite/repository/db"
+	oidcDB "github.com/rl404/image-randomizer/internal/domain/oidc/repository/db"
+	quotaDB "github.com/rl404/image-randomizer/internal/domain/quota/repository/db"
+	tokenDB "github.com/rl404/image-randomizer/internal/domain/token/repository/db"
+	totpDB "github.com/rl404/image-randomizer/internal/domain/totp/repository/db"
 	userDB "github.com/rl404/image-randomizer/internal/domain/user/repository/db"
+	viewDB "github.com/rl404/image-randomizer/internal/domain/view/repository/db"
 	"github.com/rl404/image-randomizer/internal/utils"
 )
 
@@ -28,6 +37,23 @@ func migrate() error {
 	if err := db.AutoMigrate(
 		&userDB.User{},
 		&imageDB.Image{},
+		&tokenDB.Token{},
+		&tokenDB.Session{},
+		&apikeyDB.APIKey{},
+		&oidcDB.OIDCState{},
+		&oidcDB.OIDCIdentity{},
+		&totpDB.TOTP{},
+		&totpDB.RecoveryCode{},
+		&inviteDB.Invite{},
+		&hotlinkDB.HotlinkSetting{},
+		&hotlinkDB.HotlinkBlock{},
+		&domainpolicyDB.DomainPolicy{},
+		&domainpolicyDB.DomainRule{},
+		&quotaDB.Quota{},
+		&quotaDB.QuotaUsage{},
+		&viewDB.View{},
+		&viewDB.ViewCount{},
+		&viewDB.ViewRefererCount{},
 	); err != nil {
 		return err
 	}
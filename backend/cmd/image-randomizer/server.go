@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,19 +14,46 @@ import (
 	"github.com/rl404/image-randomizer/internal/delivery/rest/api"
 	"github.com/rl404/image-randomizer/internal/delivery/rest/ping"
 	"github.com/rl404/image-randomizer/internal/delivery/rest/swagger"
+	apikeyDB "github.com/rl404/image-randomizer/internal/domain/apikey/repository/db"
+	attemptCache "github.com/rl404/image-randomizer/internal/domain/attempt/repository/cache"
+	challengeCache "github.com/rl404/image-randomizer/internal/domain/challenge/repository/cache"
+	domainpolicyRepository "github.com/rl404/image-randomizer/internal/domain/domainpolicy/repository"
+	domainpolicyCache "github.com/rl404/image-randomizer/internal/domain/domainpolicy/repository/cache"
+	domainpolicyDB "github.com/rl404/image-randomizer/internal/domain/domainpolicy/repository/db"
+	hotlinkRepository "github.com/rl404/image-randomizer/internal/domain/hotlink/repository"
+	hotlinkBatch "github.com/rl404/image-randomizer/internal/domain/hotlink/repository/batch"
+	hotlinkCache "github.com/rl404/image-randomizer/internal/domain/hotlink/repository/cache"
+	hotlinkDB "github.com/rl404/image-randomizer/internal/domain/hotlink/repository/db"
 	imageRepository "github.com/rl404/image-randomizer/internal/domain/image/repository"
 	imageCache "github.com/rl404/image-randomizer/internal/domain/image/repository/cache"
 	imageDB "github.com/rl404/image-randomizer/internal/domain/image/repository/db"
 	imageHttp "github.com/rl404/image-randomizer/internal/domain/image/repository/http"
+	inviteDB "github.com/rl404/image-randomizer/internal/domain/invite/repository/db"
+	oidcDB "github.com/rl404/image-randomizer/internal/domain/oidc/repository/db"
+	quotaRepository "github.com/rl404/image-randomizer/internal/domain/quota/repository"
+	quotaCache "github.com/rl404/image-randomizer/internal/domain/quota/repository/cache"
+	quotaDB "github.com/rl404/image-randomizer/internal/domain/quota/repository/db"
+	ratelimitCache "github.com/rl404/image-randomizer/internal/domain/ratelimit/repository/cache"
 	tokenRepository "github.com/rl404/image-randomizer/internal/domain/token/repository"
 	tokenCache "github.com/rl404/image-randomizer/internal/domain/token/repository/cache"
+	tokenDB "github.com/rl404/image-randomizer/internal/domain/token/repository/db"
+	totpDB "github.com/rl404/image-randomizer/internal/domain/totp/repository/db"
 	userRepository "github.com/rl404/image-randomizer/internal/domain/user/repository"
 	userCache "github.com/rl404/image-randomizer/internal/domain/user/repository/cache"
 	userDB "github.com/rl404/image-randomizer/internal/domain/user/repository/db"
+	viewRepository "github.com/rl404/image-randomizer/internal/domain/view/repository"
+	viewBatch "github.com/rl404/image-randomizer/internal/domain/view/repository/batch"
+	viewDB "github.com/rl404/image-randomizer/internal/domain/view/repository/db"
 	"github.com/rl404/image-randomizer/internal/service"
 	"github.com/rl404/image-randomizer/internal/utils"
 	"github.com/rl404/image-randomizer/pkg/cache"
 	"github.com/rl404/image-randomizer/pkg/http"
+	"github.com/rl404/image-randomizer/pkg/jwk"
+	"github.com/rl404/image-randomizer/pkg/oidc"
+	"github.com/rl404/image-randomizer/pkg/password"
+	"github.com/rl404/image-randomizer/pkg/pow"
+	"github.com/rl404/image-randomizer/pkg/pubsub"
+	"github.com/rl404/image-randomizer/pkg/signedurl"
 )
 
 func server() error {
@@ -60,12 +88,33 @@ func server() error {
 	utils.Info("cache initialized")
 	defer c.Close()
 
+	// Init state store. Unlike cache, the state can't be
+	// dropped so nocache falls back to in-memory.
+	state, err := cache.NewStore(cacheType[cfg.Cache.Dialect], cfg.Cache.Address, cfg.Cache.Password, cfg.Cache.Time)
+	if err != nil {
+		return err
+	}
+	utils.Info("state store initialized")
+	defer state.Close()
+
+	// Init pubsub.
+	ps, err := pubsub.New(pubsubType[cfg.PubSub.Dialect], cfg.PubSub.Address, cfg.PubSub.Password)
+	if err != nil {
+		return err
+	}
+	utils.Info("pubsub initialized")
+	defer ps.Close()
+
 	// Init in-memory.
 	im, err := cache.New(cache.InMemory, "", "", time.Minute)
 	if err != nil {
 		return err
 	}
 	im = nrCache.New("inmemory", "inmemory", im)
+	im, err = cache.NewInvalidator(im, ps, utils.GetKey("invalidation"))
+	if err != nil {
+		return err
+	}
 	utils.Info("in-memory initialized")
 	defer im.Close()
 
@@ -81,29 +130,169 @@ func server() error {
 	// Init user.
 	var user userRepository.Repository
 	user = userDB.New(db)
-	user = userCache.New(c, user)
-	user = userCache.New(im, user)
+	user = userCache.New(c, cfg.Cache.Time, user)
+	user = userCache.New(im, time.Minute, user)
 	utils.Info("repository user initialized")
 
+	// Init domain policy.
+	var domainPolicy domainpolicyRepository.Repository
+	domainPolicy = domainpolicyDB.New(db)
+	domainPolicy = domainpolicyCache.New(c, cfg.Cache.Time, domainPolicy)
+	domainPolicy = domainpolicyCache.New(im, time.Minute, domainPolicy)
+	utils.Info("repository domain policy initialized")
+
 	// Init image.
 	var image imageRepository.Repository
 	image = imageDB.New(db)
-	image = imageHttp.New(image)
-	image = imageCache.New(c, image)
-	image = imageCache.New(im, image)
+	image = imageHttp.New(image, domainPolicy)
+	image = imageCache.New(c, cfg.Cache.Time, image)
+	image = imageCache.New(im, time.Minute, image)
 	utils.Info("repository image initialized")
 
+	// Init jwt keys.
+	keys, err := jwk.New(cfg.JWT.PrivateKeyFile, cfg.JWT.PublicKeyFiles)
+	if err != nil {
+		return err
+	}
+	utils.Info("jwt keys initialized")
+
 	// Init token.
-	var token tokenRepository.Repository = tokenCache.New(c,
+	var token tokenRepository.Repository
+	token = tokenDB.New(db,
+		keys,
 		cfg.JWT.AccessSecret,
 		cfg.JWT.AccessExpired,
 		cfg.JWT.RefreshSecret,
 		cfg.JWT.RefreshExpired,
 	)
+	token = tokenCache.New(c, token, cfg.JWT.AccessExpired, cfg.JWT.RefreshExpired)
 	utils.Info("repository token initialized")
 
+	// Run expired token cleanup.
+	go cleanupToken(token, cfg.JWT.CleanupInterval)
+	utils.Info("token cleanup initialized")
+
+	// Init api key.
+	apiKey := apikeyDB.New(db)
+	utils.Info("repository api key initialized")
+
+	// Init oidc.
+	oidcRepo := oidcDB.New(db)
+	utils.Info("repository oidc initialized")
+
+	var oidcProvider *oidc.Provider
+	if cfg.OIDC.Issuer != "" {
+		oidcProvider = oidc.New(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		}, nil)
+		utils.Info("oidc provider initialized")
+	}
+
+	// Init totp.
+	totp := totpDB.New(db)
+	utils.Info("repository totp initialized")
+
+	// Init invite.
+	invite := inviteDB.New(db)
+	utils.Info("repository invite initialized")
+
+	// Init attempt.
+	attempt := attemptCache.New(state)
+	utils.Info("repository attempt initialized")
+
+	// Init registration challenge.
+	challenge := challengeCache.New(state)
+	utils.Info("repository challenge initialized")
+
+	var powChallenge *pow.POW
+	if cfg.Register.ChallengeDifficulty > 0 {
+		powChallenge = pow.New(cfg.Register.ChallengeSecret)
+		utils.Info("registration challenge initialized")
+	}
+
+	// Init hotlink.
+	hotlinkWriter := hotlinkBatch.New(hotlinkDB.New(db), cfg.Analytics.BatchSize, cfg.Analytics.FlushInterval)
+	defer hotlinkWriter.Close()
+
+	var hotlink hotlinkRepository.Repository
+	hotlink = hotlinkWriter
+	hotlink = hotlinkCache.New(c, cfg.Cache.Time, hotlink)
+	utils.Info("repository hotlink initialized")
+
+	// Init quota.
+	var quota quotaRepository.Repository
+	quota = quotaDB.New(db)
+	quota = quotaCache.New(c, cfg.Cache.Time, quota)
+	utils.Info("repository quota initialized")
+
+	// Init view.
+	viewWriter := viewBatch.New(viewDB.New(db), cfg.Analytics.BatchSize, cfg.Analytics.FlushInterval)
+	defer viewWriter.Close()
+
+	var view viewRepository.Repository
+	view = viewWriter
+	utils.Info("repository view initialized")
+
+	// Init rate limit.
+	rateLimit := ratelimitCache.New(state)
+	utils.Info("repository rate limit initialized")
+
+	// Init url signer.
+	signer := signedurl.New(cfg.SignedURL.Secret)
+	utils.Info("url signer initialized")
+
 	// Init service.
-	service := service.New(user, image, token)
+	service := service.New(user, image, token, apiKey, oidcRepo, totp, invite, attempt, challenge, hotlink, rateLimit, domainPolicy, quota, view, utils.NewTransactor(db), password.New(password.Config{
+		Pepper:       cfg.Password.Pepper,
+		LegacyPepper: cfg.Password.LegacyPepper,
+		Memory:       cfg.Password.Memory,
+		Iterations:   cfg.Password.Iterations,
+		Parallelism:  cfg.Password.Parallelism,
+	}), oidcProvider, powChallenge, signer, service.Config{
+		Token: service.TokenConfig{
+			RefreshGrace: cfg.JWT.RefreshGrace,
+		},
+		LoginLimit: service.LoginLimitConfig{
+			FreeFailures:        cfg.Login.FreeFailures,
+			UsernameMaxFailures: cfg.Login.UsernameMaxFailures,
+			IPMaxFailures:       cfg.Login.IPMaxFailures,
+			BackoffBase:         cfg.Login.BackoffBase,
+			BackoffMax:          cfg.Login.BackoffMax,
+			Lockout:             cfg.Login.Lockout,
+			Window:              cfg.Login.Window,
+		},
+		OIDC: service.OIDCConfig{
+			AutoRegister: cfg.OIDC.AutoRegister,
+			StateExpired: cfg.OIDC.StateExpired,
+		},
+		TOTP: service.TOTPConfig{
+			Issuer: cfg.TOTP.Issuer,
+		},
+		Register: service.RegisterConfig{
+			Mode:                cfg.Register.Mode,
+			ReservedUsernames:   cfg.Register.ReservedUsernames,
+			ChallengeDifficulty: cfg.Register.ChallengeDifficulty,
+			ChallengeExpired:    cfg.Register.ChallengeExpired,
+		},
+		SignedURL: service.SignedURLConfig{
+			Expired:    cfg.SignedURL.Expired,
+			MaxExpired: cfg.SignedURL.MaxExpired,
+		},
+		RateLimit: service.RateLimitConfig{
+			IPLimit:        cfg.RateLimit.IPLimit,
+			IPPeriod:       cfg.RateLimit.IPPeriod,
+			UsernameLimit:  cfg.RateLimit.UsernameLimit,
+			UsernamePeriod: cfg.RateLimit.UsernamePeriod,
+		},
+		Quota: service.QuotaConfig{
+			ImageLimit:   cfg.Quota.ImageLimit,
+			MonthlyBytes: cfg.Quota.MonthlyBytes,
+		},
+	})
 	utils.Info("service initialized")
 
 	// Init web server.
@@ -129,7 +318,14 @@ func server() error {
 	utils.Info("http route swagger initialized")
 
 	// Register api route.
-	api.New(service, cfg.JWT.AccessSecret, cfg.JWT.RefreshSecret).Register(r, nrApp)
+	api.New(service, keys, cfg.JWT.AccessSecret, cfg.JWT.RefreshSecret, cfg.JWT.AllowQuery, api.CookieConfig{
+		Enabled:        cfg.Cookie.Enabled,
+		Domain:         cfg.Cookie.Domain,
+		Secure:         cfg.Cookie.Secure,
+		SameSite:       getSameSite(cfg.Cookie.SameSite),
+		AccessExpired:  cfg.JWT.AccessExpired,
+		RefreshExpired: cfg.JWT.RefreshExpired,
+	}).Register(r, nrApp)
 	utils.Info("http route api initialized")
 
 	// Run web server.
@@ -148,3 +344,14 @@ func server() error {
 
 	return nil
 }
+
+func cleanupToken(token tokenRepository.Repository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := token.DeleteExpired(context.Background()); err != nil {
+			utils.Error(err.Error())
+		}
+	}
+}
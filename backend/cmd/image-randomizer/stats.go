@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	viewDB "github.com/rl404/image-randomizer/internal/domain/view/repository/db"
+	"github.com/rl404/image-randomizer/internal/service"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/export"
+	"github.com/spf13/cobra"
+)
+
+func statsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Manage random image view stats",
+	}
+
+	var data service.ExportStatsRequest
+	var format, output string
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export all users' view stats",
+		RunE: func(*cobra.Command, []string) error {
+			data.Format = export.Format(format)
+			return statsExport(data, output)
+		},
+	}
+	exportCmd.Flags().StringVar(&data.From, "from", "", "start date in UTC (YYYY-MM-DD), default to 30 days before to")
+	exportCmd.Flags().StringVar(&data.To, "to", "", "end date in UTC (YYYY-MM-DD), default to today")
+	exportCmd.Flags().StringVarP(&format, "format", "f", string(export.CSV), "export format (csv/jsonl)")
+	exportCmd.Flags().StringVarP(&data.Type, "type", "t", service.StatsExportRaw, "raw views or daily view count per image (raw/daily)")
+	exportCmd.Flags().Int64VarP(&data.UserID, "user", "u", 0, "only export the user id, 0 for all users")
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "output file, default to stdout")
+
+	cmd.AddCommand(exportCmd)
+
+	return cmd
+}
+
+func statsExport(data service.ExportStatsRequest, output string) error {
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+
+	db, err := newDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	tmp, _ := db.DB()
+	defer tmp.Close()
+
+	ctx := context.Background()
+
+	exp, _, err := service.NewStatsExport(ctx, viewDB.New(db), data)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if _, err := exp.Write(ctx, w); err != nil {
+		return err
+	}
+
+	if output != "" {
+		utils.Info("exported to %s", output)
+	}
+
+	return nil
+}
@@ -7,21 +7,28 @@ import (
 	"github.com/rl404/fairy/monitoring/newrelic/middleware"
 	"github.com/rl404/image-randomizer/internal/service"
 	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/jwk"
 )
 
 // API contains all functions for api endpoints.
 type API struct {
 	service       service.Service
+	keys          *jwk.KeySet
 	accessSecret  string
 	refreshSecret string
+	allowQueryJWT bool
+	cookie        CookieConfig
 }
 
 // New to create new api endpoints.
-func New(service service.Service, accessSecret string, refreshSecret string) *API {
+func New(service service.Service, keys *jwk.KeySet, accessSecret string, refreshSecret string, allowQueryJWT bool, cookie CookieConfig) *API {
 	return &API{
 		service:       service,
+		keys:          keys,
 		accessSecret:  accessSecret,
 		refreshSecret: refreshSecret,
+		allowQueryJWT: allowQueryJWT,
+		cookie:        cookie,
 	}
 }
 
@@ -29,28 +36,94 @@ func New(service service.Service, accessSecret string, refreshSecret string) *AP
 func (api *API) Register(r chi.Router, nrApp *newrelic.Application) {
 	r.Route("/", func(r chi.Router) {
 		r.Use(middleware.NewHTTP(nrApp))
-		r.Use(log.HTTPMiddlewareWithLog(utils.GetLogger(0), log.APIMiddlewareConfig{Error: true}))
-		r.Use(log.HTTPMiddlewareWithLog(utils.GetLogger(1), log.APIMiddlewareConfig{
-			RequestHeader:  true,
-			RequestBody:    true,
-			ResponseHeader: true,
-			ResponseBody:   true,
-			RawPath:        true,
-			Error:          true,
-		}))
-		r.Use(utils.Recoverer)
-
-		r.Post("/register", api.handleRegister)
-		r.Post("/login", api.handleLogin)
-
-		r.Post("/token/check", api.jwtAuth(api.handleTokenCheck))
-		r.Post("/token/refresh", api.jwtAuth(api.handleTokenRefresh, tokenRefresh))
-
-		r.Get("/images", api.jwtAuth(api.handleGetImages))
-		r.Post("/images", api.jwtAuth(api.handleCreateImage))
-		r.Patch("/images/{image_id}", api.jwtAuth(api.handleUpdateImage))
-		r.Delete("/images/{image_id}", api.jwtAuth(api.handleDeleteImage))
-
-		r.Get("/user/{username}/image.jpg", api.handleRandomImage)
+
+		// Log middleware keeps the whole response body in
+		// memory, so streamed export has its own log.
+		r.With(streamLog, utils.Recoverer).Get("/stats/export", api.jwtAuth(api.handleExportStats))
+
+		r.Group(func(r chi.Router) {
+			r.Use(log.HTTPMiddlewareWithLog(utils.GetLogger(0), log.APIMiddlewareConfig{Error: true}))
+			r.Use(log.HTTPMiddlewareWithLog(utils.GetLogger(1), log.APIMiddlewareConfig{
+				RequestHeader:  true,
+				RequestBody:    true,
+				ResponseHeader: true,
+				ResponseBody:   true,
+				RawPath:        true,
+				Error:          true,
+			}))
+			r.Use(utils.Recoverer)
+
+			r.Get("/.well-known/jwks.json", api.handleJWKS)
+
+			r.Get("/register/challenge", api.handleGetRegisterChallenge)
+			r.Post("/register", api.handleRegister)
+			r.Post("/login", api.handleLogin)
+			r.Post("/login/2fa", api.jwtAuth(api.handleLogin2FA, tokenChallenge))
+			r.Post("/logout", api.jwtAuth(api.handleLogout))
+			r.Post("/logout/all", api.jwtAuth(api.handleLogoutAll))
+
+			r.Get("/oidc/login", api.handleOIDCLogin)
+			r.Get("/oidc/callback", api.handleOIDCCallback)
+			r.Post("/oidc/link", api.jwtAuth(api.handleOIDCLink))
+
+			r.Post("/user/password", api.jwtAuth(api.handleChangePassword))
+			r.Delete("/user", api.jwtAuth(api.handleDeleteUser))
+			r.Post("/user/2fa", api.jwtAuth(api.handleEnrollTOTP))
+			r.Post("/user/2fa/confirm", api.jwtAuth(api.handleConfirmTOTP))
+			r.Delete("/user/2fa", api.jwtAuth(api.handleDisableTOTP))
+
+			r.Get("/user/list", api.jwtAuth(api.handleGetList))
+			r.Patch("/user/list", api.jwtAuth(api.handleUpdateList))
+			r.Post("/user/list/token", api.jwtAuth(api.handleRotateListToken))
+			r.Get("/user/list/url", api.jwtAuth(api.handleGetListURL))
+
+			r.Get("/user/hotlink", api.jwtAuth(api.handleGetHotlinkSetting))
+			r.Put("/user/hotlink", api.jwtAuth(api.handleUpdateHotlinkSetting))
+			r.Get("/user/hotlink/blocked", api.jwtAuth(api.handleGetHotlinkBlocks))
+
+			r.Get("/user/usage", api.jwtAuth(api.handleGetUsage))
+
+			r.Get("/stats", api.jwtAuth(api.handleGetStats))
+
+			r.Post("/token/check", api.jwtAuth(api.handleTokenCheck))
+			r.Post("/token/refresh", api.jwtAuth(api.handleTokenRefresh, tokenRefresh))
+
+			r.Get("/sessions", api.jwtAuth(api.handleGetSessions))
+			r.Delete("/sessions/{session_id}", api.jwtAuth(api.handleDeleteSession))
+
+			r.Get("/api-keys", api.jwtAuth(api.handleGetAPIKeys))
+			r.Post("/api-keys", api.jwtAuth(api.handleCreateAPIKey))
+			r.Delete("/api-keys/{api_key_id}", api.jwtAuth(api.handleDeleteAPIKey))
+
+			r.Get("/images", api.apiKeyAuth(api.handleGetImages, service.ScopeImagesRead))
+			r.Post("/images", api.apiKeyAuth(api.handleCreateImage, service.ScopeImagesWrite))
+			r.Patch("/images/{image_id}", api.apiKeyAuth(api.handleUpdateImage, service.ScopeImagesWrite))
+			r.Delete("/images/{image_id}", api.apiKeyAuth(api.handleDeleteImage, service.ScopeImagesWrite))
+			r.Get("/images/{image_id}/url", api.apiKeyAuth(api.handleCreateImageURL, service.ScopeImagesRead))
+			r.Get("/images/{image_id}/preview", api.handleGetImagePreview)
+
+			r.Get("/user/{username}/image.jpg", api.handleRandomImage)
+
+			r.Route("/admin", func(r chi.Router) {
+				r.Get("/users", api.adminAuth(api.handleAdminGetUsers))
+				r.Get("/users/{user_id}/images", api.adminAuth(api.handleAdminGetUserImages))
+				r.Delete("/users/{user_id}/images", api.adminAuth(api.handleAdminDeleteImages))
+				r.Delete("/users/{user_id}/images/{image_id}", api.adminAuth(api.handleAdminDeleteImage))
+				r.Post("/users/{user_id}/suspend", api.adminAuth(api.handleAdminSuspendUser))
+				r.Delete("/users/{user_id}/suspend", api.adminAuth(api.handleAdminUnsuspendUser))
+				r.Post("/users/{user_id}/logout", api.adminAuth(api.handleAdminLogoutUser))
+				r.Get("/users/{user_id}/usage", api.adminAuth(api.handleAdminGetUserUsage))
+				r.Put("/users/{user_id}/quota", api.adminAuth(api.handleAdminUpdateQuota))
+
+				r.Get("/domains", api.adminAuth(api.handleAdminGetDomainPolicy))
+				r.Put("/domains/mode", api.adminAuth(api.handleAdminUpdateDomainMode))
+				r.Post("/domains/rules", api.adminAuth(api.handleAdminCreateDomainRule))
+				r.Delete("/domains/rules/{rule_id}", api.adminAuth(api.handleAdminDeleteDomainRule))
+
+				r.Get("/invites", api.adminAuth(api.handleAdminGetInvites))
+				r.Post("/invites", api.adminAuth(api.handleAdminCreateInvite))
+				r.Delete("/invites/{invite_id}", api.adminAuth(api.handleAdminDeleteInvite))
+			})
+		})
 	})
 }
@@ -0,0 +1,416 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/service"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// @summary Get users.
+// @description Including suspended and deleted users.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param query query string false "search username"
+// @param page query integer false "page" default(1)
+// @param limit query integer false "limit" default(20)
+// @success 200 {object} utils.Response{data=service.AdminUsers}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/users [get]
+func (api *API) handleAdminGetUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	users, code, err := api.service.AdminGetUsers(r.Context(), service.AdminGetUsersRequest{
+		Query: query.Get("query"),
+		Page:  page,
+		Limit: limit,
+	})
+
+	utils.ResponseWithJSON(w, code, users, stack.Wrap(r.Context(), err))
+}
+
+// @summary Get user's images.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param user_id path integer true "user id"
+// @success 200 {object} utils.Response{data=[]service.Image}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/users/{user_id}/images [get]
+func (api *API) handleAdminGetUserImages(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	images, code, err := api.service.AdminGetUserImages(r.Context(), userID)
+	utils.ResponseWithJSON(w, code, images, stack.Wrap(r.Context(), err))
+}
+
+// @summary Suspend user.
+// @description User's sessions will be revoked and random image will return 403.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param user_id path integer true "user id"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/users/{user_id}/suspend [post]
+func (api *API) handleAdminSuspendUser(w http.ResponseWriter, r *http.Request) {
+	api.adminSuspendUser(w, r, true)
+}
+
+// @summary Unsuspend user.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param user_id path integer true "user id"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/users/{user_id}/suspend [delete]
+func (api *API) handleAdminUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	api.adminSuspendUser(w, r, false)
+}
+
+func (api *API) adminSuspendUser(w http.ResponseWriter, r *http.Request, suspend bool) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	code, err = api.service.AdminSuspendUser(r.Context(), service.AdminSuspendUserRequest{
+		AdminID: claims.UserID,
+		UserID:  userID,
+		Suspend: suspend,
+	})
+
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
+
+// @summary Force logout user.
+// @description Revoke all sessions of the user.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param user_id path integer true "user id"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/users/{user_id}/logout [post]
+func (api *API) handleAdminLogoutUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	code, err := api.service.AdminLogoutUser(r.Context(), userID)
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
+
+// @summary Delete all user's images.
+// @description Recorded views and monthly proxied bytes are kept.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param user_id path integer true "user id"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/users/{user_id}/images [delete]
+func (api *API) handleAdminDeleteImages(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	code, err := api.service.AdminDeleteImage(r.Context(), service.AdminDeleteImageRequest{
+		UserID: userID,
+	})
+
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
+
+// @summary Delete user's image.
+// @description Recorded views and monthly proxied bytes are kept.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param user_id path integer true "user id"
+// @param image_id path integer true "image id"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/users/{user_id}/images/{image_id} [delete]
+func (api *API) handleAdminDeleteImage(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	imageID, err := strconv.ParseInt(chi.URLParam(r, "image_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	code, err := api.service.AdminDeleteImage(r.Context(), service.AdminDeleteImageRequest{
+		UserID:  userID,
+		ImageID: imageID,
+	})
+
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
+
+// @summary Get user's quota usage.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param user_id path integer true "user id"
+// @success 200 {object} utils.Response{data=service.Usage}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/users/{user_id}/usage [get]
+func (api *API) handleAdminGetUserUsage(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	usage, code, err := api.service.AdminGetUserUsage(r.Context(), userID)
+	utils.ResponseWithJSON(w, code, usage, stack.Wrap(r.Context(), err))
+}
+
+// @summary Update user's quota.
+// @description Override the default quota. Set null to use default quota and 0 for unlimited.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param user_id path integer true "user id"
+// @param request body service.AdminUpdateQuotaRequest true "request body"
+// @success 200 {object} utils.Response{data=service.Usage}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/users/{user_id}/quota [put]
+func (api *API) handleAdminUpdateQuota(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	var request service.AdminUpdateQuotaRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	request.UserID = userID
+
+	usage, code, err := api.service.AdminUpdateQuota(r.Context(), request)
+	utils.ResponseWithJSON(w, code, usage, stack.Wrap(r.Context(), err))
+}
+
+// @summary Get image domain policy.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @success 200 {object} utils.Response{data=service.DomainPolicy}
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/domains [get]
+func (api *API) handleAdminGetDomainPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, code, err := api.service.AdminGetDomainPolicy(r.Context())
+	utils.ResponseWithJSON(w, code, policy, stack.Wrap(r.Context(), err))
+}
+
+// @summary Update image domain policy mode.
+// @description Blocklist mode blocks image from hosts in the rules.
+// @description Allowlist mode only allows image from hosts in the rules.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param request body service.AdminUpdateDomainModeRequest true "request body"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/domains/mode [put]
+func (api *API) handleAdminUpdateDomainMode(w http.ResponseWriter, r *http.Request) {
+	var request service.AdminUpdateDomainModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	code, err := api.service.AdminUpdateDomainMode(r.Context(), request)
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
+
+// @summary Create image domain policy rule.
+// @description Pattern *.example.com matches subdomains but not example.com itself.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param request body service.AdminCreateDomainRuleRequest true "request body"
+// @success 201 {object} utils.Response{data=service.DomainRule}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/domains/rules [post]
+func (api *API) handleAdminCreateDomainRule(w http.ResponseWriter, r *http.Request) {
+	var request service.AdminCreateDomainRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	rule, code, err := api.service.AdminCreateDomainRule(r.Context(), request)
+	utils.ResponseWithJSON(w, code, rule, stack.Wrap(r.Context(), err))
+}
+
+// @summary Delete image domain policy rule.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param rule_id path integer true "rule id"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/domains/rules/{rule_id} [delete]
+func (api *API) handleAdminDeleteDomainRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := strconv.ParseInt(chi.URLParam(r, "rule_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	code, err := api.service.AdminDeleteDomainRule(r.Context(), ruleID)
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
+
+// @summary Get registration invites.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @success 200 {object} utils.Response{data=[]service.Invite}
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/invites [get]
+func (api *API) handleAdminGetInvites(w http.ResponseWriter, r *http.Request) {
+	invites, code, err := api.service.AdminGetInvites(r.Context())
+	utils.ResponseWithJSON(w, code, invites, stack.Wrap(r.Context(), err))
+}
+
+// @summary Create registration invite.
+// @description Invite code is generated and used when registration mode is invite.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param request body service.AdminCreateInviteRequest true "request body"
+// @success 201 {object} utils.Response{data=service.Invite}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/invites [post]
+func (api *API) handleAdminCreateInvite(w http.ResponseWriter, r *http.Request) {
+	var request service.AdminCreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	invite, code, err := api.service.AdminCreateInvite(r.Context(), request)
+	utils.ResponseWithJSON(w, code, invite, stack.Wrap(r.Context(), err))
+}
+
+// @summary Delete registration invite.
+// @tags Admin
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param invite_id path integer true "invite id"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /admin/invites/{invite_id} [delete]
+func (api *API) handleAdminDeleteInvite(w http.ResponseWriter, r *http.Request) {
+	inviteID, err := strconv.ParseInt(chi.URLParam(r, "invite_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	code, err := api.service.AdminDeleteInvite(r.Context(), inviteID)
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/service"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// @summary Get api keys.
+// @tags API Key
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @success 200 {object} utils.Response{data=[]service.APIKey}
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /api-keys [get]
+func (api *API) handleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	keys, code, err := api.service.GetAPIKeys(r.Context(), claims.UserID)
+	utils.ResponseWithJSON(w, code, keys, stack.Wrap(r.Context(), err))
+}
+
+// @summary Create api key.
+// @description The key is only shown once in the response.
+// @tags API Key
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param request body service.CreateAPIKeyRequest true "request body"
+// @success 201 {object} utils.Response{data=service.APIKey}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /api-keys [post]
+func (api *API) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	var request service.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	request.UserID = claims.UserID
+
+	key, code, err := api.service.CreateAPIKey(r.Context(), request)
+	utils.ResponseWithJSON(w, code, key, stack.Wrap(r.Context(), err))
+}
+
+// @summary Delete api key.
+// @tags API Key
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param api_key_id path integer true "api key id"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /api-keys/{api_key_id} [delete]
+func (api *API) handleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	apiKeyID, err := strconv.ParseInt(chi.URLParam(r, "api_key_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	code, err = api.service.DeleteAPIKey(r.Context(), service.DeleteAPIKeyRequest{
+		UserID: claims.UserID,
+		ID:     apiKeyID,
+	})
+
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
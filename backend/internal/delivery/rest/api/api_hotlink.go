@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/service"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// @summary Get hotlink protection setting.
+// @tags Hotlink
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @success 200 {object} utils.Response{data=service.HotlinkSetting}
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/hotlink [get]
+func (api *API) handleGetHotlinkSetting(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	setting, code, err := api.service.GetHotlinkSetting(r.Context(), claims.UserID)
+	utils.ResponseWithJSON(w, code, setting, stack.Wrap(r.Context(), err))
+}
+
+// @summary Update hotlink protection setting.
+// @description Random image will only be served to request with origin or referer in the allowlist.
+// @description Empty allowlist disables the protection.
+// @tags Hotlink
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param request body service.UpdateHotlinkSettingRequest true "request body"
+// @success 200 {object} utils.Response{data=service.HotlinkSetting}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/hotlink [put]
+func (api *API) handleUpdateHotlinkSetting(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	var request service.UpdateHotlinkSettingRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	request.UserID = claims.UserID
+
+	setting, code, err := api.service.UpdateHotlinkSetting(r.Context(), request)
+	utils.ResponseWithJSON(w, code, setting, stack.Wrap(r.Context(), err))
+}
+
+// @summary Get blocked hotlink hosts.
+// @description Most blocked hosts. Empty host means request without origin and referer.
+// @tags Hotlink
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @success 200 {object} utils.Response{data=[]service.HotlinkBlock}
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/hotlink/blocked [get]
+func (api *API) handleGetHotlinkBlocks(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	blocks, code, err := api.service.GetHotlinkBlocks(r.Context(), claims.UserID)
+	utils.ResponseWithJSON(w, code, blocks, stack.Wrap(r.Context(), err))
+}
@@ -15,10 +15,11 @@ import (
 // @summary Get images.
 // @tags Image
 // @produce json
-// @param Authorization header string true "Bearer jwt.access.token"
+// @param Authorization header string true "Bearer jwt.access.token or ApiKey ir_api_key"
 // @success 200 {object} utils.Response{data=[]service.Image}
 // @failure 400 {object} utils.Response
 // @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
 // @failure 500 {object} utils.Response
 // @router /images [get]
 func (api *API) handleGetImages(w http.ResponseWriter, r *http.Request) {
@@ -35,11 +36,13 @@ func (api *API) handleGetImages(w http.ResponseWriter, r *http.Request) {
 // @summary Create image.
 // @tags Image
 // @produce json
-// @param Authorization header string true "Bearer jwt.access.token"
+// @param Authorization header string true "Bearer jwt.access.token or ApiKey ir_api_key"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
 // @param request body service.CreateImageRequest true "request body"
 // @success 201 {object} utils.Response{data=service.Image}
 // @failure 400 {object} utils.Response
 // @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
 // @failure 500 {object} utils.Response
 // @router /images [post]
 func (api *API) handleCreateImage(w http.ResponseWriter, r *http.Request) {
@@ -64,11 +67,13 @@ func (api *API) handleCreateImage(w http.ResponseWriter, r *http.Request) {
 // @summary Update image.
 // @tags Image
 // @produce json
-// @param Authorization header string true "Bearer jwt.access.token"
+// @param Authorization header string true "Bearer jwt.access.token or ApiKey ir_api_key"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
 // @param request body service.UpdateImageRequest true "request body"
 // @success 200 {object} utils.Response
 // @failure 400 {object} utils.Response
 // @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
 // @failure 404 {object} utils.Response
 // @failure 500 {object} utils.Response
 // @router /images/{image_id} [patch]
@@ -101,10 +106,12 @@ func (api *API) handleUpdateImage(w http.ResponseWriter, r *http.Request) {
 // @summary Delete image.
 // @tags Image
 // @produce json
-// @param Authorization header string true "Bearer jwt.access.token"
+// @param Authorization header string true "Bearer jwt.access.token or ApiKey ir_api_key"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
 // @success 200 {object} utils.Response
 // @failure 400 {object} utils.Response
 // @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
 // @failure 404 {object} utils.Response
 // @failure 500 {object} utils.Response
 // @router /images/{image_id} [delete]
@@ -128,3 +135,81 @@ func (api *API) handleDeleteImage(w http.ResponseWriter, r *http.Request) {
 
 	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
 }
+
+// @summary Create image signed url.
+// @description Short-lived url to preview the image without session.
+// @tags Image
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token or ApiKey ir_api_key"
+// @param image_id path integer true "image id"
+// @param expired_in query integer false "expiration in seconds"
+// @success 200 {object} utils.Response{data=service.SignedURL}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /images/{image_id}/url [get]
+func (api *API) handleCreateImageURL(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	imageID, err := strconv.ParseInt(chi.URLParam(r, "image_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	var expiredIn int
+	if str := r.URL.Query().Get("expired_in"); str != "" {
+		if expiredIn, err = strconv.Atoi(str); err != nil {
+			utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+			return
+		}
+	}
+
+	url, code, err := api.service.CreateImageURL(r.Context(), service.CreateImageURLRequest{
+		UserID:    claims.UserID,
+		ImageID:   imageID,
+		ExpiredIn: expiredIn,
+	})
+
+	utils.ResponseWithJSON(w, code, url, stack.Wrap(r.Context(), err))
+}
+
+// @summary Get image preview.
+// @description Use signed url from create image signed url.
+// @tags Image
+// @produce json,jpeg
+// @param image_id path integer true "image id"
+// @param expires query integer true "expiration unix timestamp"
+// @param signature query string true "url signature"
+// @success 200
+// @failure 400 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 429 {object} utils.Response
+// @header 429 {integer} Retry-After "seconds until monthly bandwidth quota is reset"
+// @failure 500 {object} utils.Response
+// @router /images/{image_id}/preview [get]
+func (api *API) handleGetImagePreview(w http.ResponseWriter, r *http.Request) {
+	imageID, err := strconv.ParseInt(chi.URLParam(r, "image_id"), 10, 64)
+	if err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	image, code, err := api.service.GetImagePreview(r.Context(), service.GetImagePreviewRequest{
+		ImageID: imageID,
+		Query:   r.URL.Query(),
+	})
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	utils.ResponseWithImage(r.Context(), w, image)
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/service"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// @summary Get random image list setting.
+// @tags List
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @success 200 {object} utils.Response{data=service.List}
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/list [get]
+func (api *API) handleGetList(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	list, code, err := api.service.GetList(r.Context(), claims.UserID)
+	utils.ResponseWithJSON(w, code, list, stack.Wrap(r.Context(), err))
+}
+
+// @summary Update random image list visibility.
+// @description Public list can be accessed by anyone with the username.
+// @description Unlisted list requires list token in the url.
+// @description Private list requires signed url.
+// @tags List
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param request body service.UpdateListRequest true "request body"
+// @success 200 {object} utils.Response{data=service.List}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/list [patch]
+func (api *API) handleUpdateList(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	var request service.UpdateListRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	request.UserID = claims.UserID
+
+	list, code, err := api.service.UpdateList(r.Context(), request)
+	utils.ResponseWithJSON(w, code, list, stack.Wrap(r.Context(), err))
+}
+
+// @summary Rotate random image list token.
+// @description Old unlisted and private urls will not work anymore.
+// @tags List
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @success 200 {object} utils.Response{data=service.List}
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/list/token [post]
+func (api *API) handleRotateListToken(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	list, code, err := api.service.RotateListToken(r.Context(), claims.UserID)
+	utils.ResponseWithJSON(w, code, list, stack.Wrap(r.Context(), err))
+}
+
+// @summary Get random image list url.
+// @description Return signed url for private list.
+// @tags List
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param expired_in query integer false "signed url expiration in seconds, never expired if empty"
+// @success 200 {object} utils.Response{data=service.ListURL}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/list/url [get]
+func (api *API) handleGetListURL(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	var expiredIn int
+	if str := r.URL.Query().Get("expired_in"); str != "" {
+		if expiredIn, err = strconv.Atoi(str); err != nil {
+			utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+			return
+		}
+	}
+
+	url, code, err := api.service.GetListURL(r.Context(), service.GetListURLRequest{
+		UserID:    claims.UserID,
+		ExpiredIn: expiredIn,
+	})
+
+	utils.ResponseWithJSON(w, code, url, stack.Wrap(r.Context(), err))
+}
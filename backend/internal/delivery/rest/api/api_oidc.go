@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/service"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// @summary Login with oidc.
+// @description Redirect to oidc provider login page.
+// @tags OIDC
+// @produce json
+// @success 302
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /oidc/login [get]
+func (api *API) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	url, code, err := api.service.GetOIDCURL(r.Context(), 0)
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	api.setOIDCStateCookie(w, url)
+	http.Redirect(w, r, url.URL, http.StatusFound)
+}
+
+// @summary Link oidc identity.
+// @description Get oidc provider login url to link the identity to current user.
+// @description The url must be opened in the same browser since the flow is bound to a state cookie.
+// @tags OIDC
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @success 200 {object} utils.Response{data=service.OIDCURL}
+// @failure 401 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /oidc/link [post]
+func (api *API) handleOIDCLink(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	url, code, err := api.service.GetOIDCURL(r.Context(), claims.UserID)
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	api.setOIDCStateCookie(w, url)
+	utils.ResponseWithJSON(w, code, url, nil)
+}
+
+// @summary Oidc callback.
+// @description Return access and refresh token if login, challenge token if two-factor is enabled, or empty data if linking.
+// @description Require oidc_state cookie set when starting the flow.
+// @tags OIDC
+// @produce json
+// @param code query string false "authorization code"
+// @param state query string true "state"
+// @success 200 {object} utils.Response{data=service.Token}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 403 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /oidc/callback [get]
+func (api *API) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var stateCookie string
+	if c, err := r.Cookie(cookieOIDCState); err == nil {
+		stateCookie = c.Value
+	}
+
+	// State can only be used once.
+	api.clearOIDCStateCookie(w)
+
+	token, code, err := api.service.OIDCCallback(r.Context(), service.OIDCCallbackRequest{
+		Code:             query.Get("code"),
+		State:            query.Get("state"),
+		StateCookie:      stateCookie,
+		Error:            query.Get("error"),
+		ErrorDescription: query.Get("error_description"),
+		IP:               utils.GetIP(r),
+		UserAgent:        r.UserAgent(),
+	})
+
+	api.responseWithToken(w, r, code, token, err)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/service"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// @summary Get login sessions.
+// @tags Session
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @success 200 {object} utils.Response{data=[]service.Session}
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /sessions [get]
+func (api *API) handleGetSessions(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	sessions, code, err := api.service.GetSessions(r.Context(), *claims)
+	utils.ResponseWithJSON(w, code, sessions, stack.Wrap(r.Context(), err))
+}
+
+// @summary Delete login session.
+// @tags Session
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param session_id path string true "session id"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /sessions/{session_id} [delete]
+func (api *API) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	code, err = api.service.DeleteSession(r.Context(), service.DeleteSessionRequest{
+		UserID:    claims.UserID,
+		SessionID: chi.URLParam(r, "session_id"),
+	})
+
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
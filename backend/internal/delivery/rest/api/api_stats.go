@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/service"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/export"
+)
+
+// @summary Get random image view stats.
+// @description Views are saved asynchronously so the latest views may not be counted yet.
+// @description Date range is limited to 366 days.
+// @tags Stats
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param from query string false "start date in UTC (YYYY-MM-DD), default to 30 days before to"
+// @param to query string false "end date in UTC (YYYY-MM-DD), default to today"
+// @success 200 {object} utils.Response{data=service.Stats}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /stats [get]
+func (api *API) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	stats, code, err := api.service.GetStats(r.Context(), service.GetStatsRequest{
+		UserID: claims.UserID,
+		From:   r.URL.Query().Get("from"),
+		To:     r.URL.Query().Get("to"),
+	})
+
+	utils.ResponseWithJSON(w, code, stats, stack.Wrap(r.Context(), err))
+}
+
+// @summary Export random image view stats.
+// @description Stream raw views or daily view count per image as CSV or JSON lines.
+// @description Date range is limited to 366 days.
+// @tags Stats
+// @produce json,plain
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param from query string false "start date in UTC (YYYY-MM-DD), default to 30 days before to"
+// @param to query string false "end date in UTC (YYYY-MM-DD), default to today"
+// @param format query string false "export format" enums(csv,jsonl) default(csv)
+// @param type query string false "raw views or daily view count per image" enums(raw,daily) default(raw)
+// @success 200
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /stats/export [get]
+func (api *API) handleExportStats(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	query := r.URL.Query()
+
+	exp, code, err := api.service.ExportStats(r.Context(), service.ExportStatsRequest{
+		UserID: claims.UserID,
+		From:   query.Get("from"),
+		To:     query.Get("to"),
+		Format: export.Format(query.Get("format")),
+		Type:   query.Get("type"),
+	})
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	// Export may take longer than server write timeout.
+	// Still try to export if failed.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		utils.Error(stack.Wrap(r.Context(), err).Error())
+	}
+
+	w.Header().Set("Content-Type", exp.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exp.Filename))
+
+	// Status code is already sent.
+	if _, err := exp.Write(r.Context(), w); err != nil {
+		utils.Error(stack.Wrap(r.Context(), err).Error())
+	}
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/service"
 	"github.com/rl404/image-randomizer/internal/utils"
 )
 
@@ -21,9 +23,11 @@ func (api *API) handleTokenCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 // @summary Refresh Token
+// @description Refresh token can also be sent in cookie if cookie session is enabled.
 // @tags Token
 // @produce json
-// @param Authorization header string true "Bearer jwt.refresh.token"
+// @param Authorization header string false "Bearer jwt.refresh.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
 // @success 200 {object} utils.Response{data=service.Token}
 // @failure 401 {object} utils.Response
 // @failure 500 {object} utils.Response
@@ -35,10 +39,25 @@ func (api *API) handleTokenRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, code, err := api.service.RefreshToken(r.Context(), *claims)
-	if err == nil {
-		token.RefreshToken = api.getJWTFromRequest(r)
-	}
+	token, code, err := api.service.RefreshToken(r.Context(), service.RefreshTokenRequest{
+		UserID:      claims.UserID,
+		RefreshUUID: claims.RefreshUUID,
+		FamilyID:    claims.FamilyID,
+		IP:          utils.GetIP(r),
+		UserAgent:   r.UserAgent(),
+	})
+
+	api.responseWithToken(w, r, code, token, err)
+}
 
-	utils.ResponseWithJSON(w, code, token, stack.Wrap(r.Context(), err))
+// @summary Get JSON Web Key Set
+// @description Public keys to verify the jwt token.
+// @tags Token
+// @produce json
+// @success 200 {object} jwk.JWKS
+// @router /.well-known/jwks.json [get]
+func (api *API) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(api.keys.JWKS())
 }
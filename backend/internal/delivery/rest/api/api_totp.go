@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/service"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// @summary Enroll two-factor authentication.
+// @description Generate totp secret and otpauth uri. Need to be confirmed to enable it.
+// @tags User
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @success 200 {object} utils.Response{data=service.TOTPEnrollment}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/2fa [post]
+func (api *API) handleEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	enrollment, code, err := api.service.EnrollTOTP(r.Context(), claims.UserID)
+	utils.ResponseWithJSON(w, code, enrollment, stack.Wrap(r.Context(), err))
+}
+
+// @summary Confirm two-factor authentication.
+// @description Enable two-factor authentication and return recovery codes. Recovery codes are only shown once.
+// @tags User
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param request body service.ConfirmTOTPRequest true "request body"
+// @success 200 {object} utils.Response{data=service.RecoveryCodes}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/2fa/confirm [post]
+func (api *API) handleConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	var request service.ConfirmTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	request.UserID = claims.UserID
+
+	codes, code, err := api.service.ConfirmTOTP(r.Context(), request)
+	utils.ResponseWithJSON(w, code, codes, stack.Wrap(r.Context(), err))
+}
+
+// @summary Disable two-factor authentication.
+// @tags User
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param request body service.DisableTOTPRequest true "request body"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/2fa [delete]
+func (api *API) handleDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	var request service.DisableTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	request.UserID = claims.UserID
+
+	code, err = api.service.DisableTOTP(r.Context(), request)
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// @summary Get quota usage.
+// @description Image count and monthly proxied bytes of random image and image preview.
+// @description Lists are not limited since each user only has a single list.
+// @tags User
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @success 200 {object} utils.Response{data=service.Usage}
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/usage [get]
+func (api *API) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	usage, code, err := api.service.GetUsage(r.Context(), claims.UserID)
+	utils.ResponseWithJSON(w, code, usage, stack.Wrap(r.Context(), err))
+}
@@ -11,12 +11,27 @@ import (
 	"github.com/rl404/image-randomizer/internal/utils"
 )
 
+// @summary Get registration challenge.
+// @description Find nonce so that sha256(challenge + nonce) has at least difficulty leading zero bits,
+// @description then send both challenge and nonce in register request.
+// @tags User
+// @produce json
+// @success 200 {object} utils.Response{data=service.RegisterChallenge}
+// @failure 404 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /register/challenge [get]
+func (api *API) handleGetRegisterChallenge(w http.ResponseWriter, r *http.Request) {
+	challenge, code, err := api.service.GetRegisterChallenge(r.Context())
+	utils.ResponseWithJSON(w, code, challenge, stack.Wrap(r.Context(), err))
+}
+
 // @summary Register.
 // @tags User
 // @produce json
 // @param request body service.RegisterRequest true "request body"
 // @success 201 {object} utils.Response{data=service.Token}
 // @failure 400 {object} utils.Response
+// @failure 403 {object} utils.Response
 // @failure 500 {object} utils.Response
 // @router /register [post]
 func (api *API) handleRegister(w http.ResponseWriter, r *http.Request) {
@@ -26,16 +41,22 @@ func (api *API) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	request.IP = utils.GetIP(r)
+	request.UserAgent = r.UserAgent()
+
 	token, code, err := api.service.Register(r.Context(), request)
-	utils.ResponseWithJSON(w, code, token, stack.Wrap(r.Context(), err))
+	api.responseWithToken(w, r, code, token, err)
 }
 
 // @summary Login.
+// @description Return challenge token instead if two-factor authentication is enabled.
 // @tags User
 // @produce json
 // @param request body service.LoginRequest true "request body"
 // @success 200 {object} utils.Response{data=service.Token}
 // @failure 400 {object} utils.Response
+// @failure 429 {object} utils.Response
+// @header 429 {integer} Retry-After "seconds to wait before retrying"
 // @failure 500 {object} utils.Response
 // @router /login [post]
 func (api *API) handleLogin(w http.ResponseWriter, r *http.Request) {
@@ -45,19 +66,190 @@ func (api *API) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	request.IP = utils.GetIP(r)
+	request.UserAgent = r.UserAgent()
+
 	token, code, err := api.service.Login(r.Context(), request)
-	utils.ResponseWithJSON(w, code, token, stack.Wrap(r.Context(), err))
+	api.responseWithToken(w, r, code, token, err)
+}
+
+// @summary Login with two-factor code.
+// @description Exchange challenge token and totp or recovery code with access and refresh token.
+// @tags User
+// @produce json
+// @param Authorization header string true "Bearer jwt.challenge.token"
+// @param request body service.Login2FARequest true "request body"
+// @success 200 {object} utils.Response{data=service.Token}
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 429 {object} utils.Response
+// @header 429 {integer} Retry-After "seconds to wait before retrying"
+// @failure 500 {object} utils.Response
+// @router /login/2fa [post]
+func (api *API) handleLogin2FA(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	var request service.Login2FARequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	request.UserID = claims.UserID
+	request.ChallengeUUID = claims.ChallengeUUID
+	request.IP = utils.GetIP(r)
+	request.UserAgent = r.UserAgent()
+
+	token, code, err := api.service.Login2FA(r.Context(), request)
+	api.responseWithToken(w, r, code, token, err)
+}
+
+// @summary Logout.
+// @tags User
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @success 200 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /logout [post]
+func (api *API) handleLogout(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	code, err = api.service.Logout(r.Context(), *claims)
+	api.clearTokenCookies(w)
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
+
+// @summary Logout from all sessions.
+// @tags User
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @success 200 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /logout/all [post]
+func (api *API) handleLogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	code, err = api.service.LogoutAll(r.Context(), claims.UserID)
+	api.clearTokenCookies(w)
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
+
+// @summary Change password.
+// @description Other sessions will be revoked. Old password can be empty if user has no password yet (registered with oidc).
+// @tags User
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param request body service.ChangePasswordRequest true "request body"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user/password [post]
+func (api *API) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	var request service.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	request.UserID = claims.UserID
+	request.FamilyID = claims.FamilyID
+
+	code, err = api.service.ChangePassword(r.Context(), request)
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+}
+
+// @summary Delete user.
+// @description Delete user account, their images, and sessions.
+// @tags User
+// @produce json
+// @param Authorization header string true "Bearer jwt.access.token"
+// @param X-CSRF-Token header string false "csrf_token cookie value, required if using cookie session"
+// @param request body service.DeleteUserRequest true "request body"
+// @success 200 {object} utils.Response
+// @failure 400 {object} utils.Response
+// @failure 401 {object} utils.Response
+// @failure 500 {object} utils.Response
+// @router /user [delete]
+func (api *API) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	claims, code, err := api.getJWTClaimFromContext(r.Context())
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	var request service.DeleteUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.ResponseWithJSON(w, http.StatusBadRequest, nil, stack.Wrap(r.Context(), err, errors.ErrInvalidRequestFormat))
+		return
+	}
+
+	request.UserID = claims.UserID
+
+	code, err = api.service.DeleteUser(r.Context(), request)
+	utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
 }
 
 // @summary Get random image.
 // @tags User
 // @produce json,jpeg
+// @param username path string true "username"
+// @param token query string false "list token, required for unlisted list"
+// @param expires query integer false "signed url expiration unix timestamp, for private list"
+// @param signature query string false "signed url signature, required for private list"
 // @success 200
+// @failure 403 {object} utils.Response
 // @failure 404 {object} utils.Response
+// @failure 410 {object} utils.Response
+// @failure 429 {object} utils.Response
+// @header 429 {integer} Retry-After "seconds to wait before retrying"
+// @header 200,429 {integer} RateLimit-Limit "requests allowed per window"
+// @header 200,429 {integer} RateLimit-Remaining "remaining requests"
+// @header 200,429 {integer} RateLimit-Reset "seconds until the window is reset"
+// @header 200,429 {string} RateLimit-Policy "requests allowed and window in seconds"
 // @failure 500 {object} utils.Response
 // @router /user/{username}/image.jpg [get]
 func (api *API) handleRandomImage(w http.ResponseWriter, r *http.Request) {
-	image, code, err := api.service.GetRandomImage(r.Context(), chi.URLParam(r, "username"))
+	limit, code, err := api.service.TakeRandomImageLimit(r.Context(), service.TakeRandomImageLimitRequest{
+		IP:       utils.GetIP(r),
+		Username: chi.URLParam(r, "username"),
+	})
+	api.setRateLimitHeader(w, limit)
+	if err != nil {
+		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+		return
+	}
+
+	image, code, err := api.service.GetRandomImage(r.Context(), service.GetRandomImageRequest{
+		Username:  chi.URLParam(r, "username"),
+		Query:     r.URL.Query(),
+		Origin:    r.Header.Get("Origin"),
+		Referer:   r.Referer(),
+		UserAgent: r.UserAgent(),
+	})
 	if err != nil {
 		utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
 		return
@@ -19,13 +19,29 @@ type tokenType int8
 const (
 	tokenAccess tokenType = iota + 1
 	tokenRefresh
+	tokenChallenge
 )
 
-func (api *API) getJWTFromRequest(r *http.Request) string {
+func (t tokenType) String() string {
+	switch t {
+	case tokenAccess:
+		return "access"
+	case tokenRefresh:
+		return "refresh"
+	case tokenChallenge:
+		return "challenge"
+	default:
+		return ""
+	}
+}
+
+func (api *API) getJWTFromRequest(r *http.Request, tokenType tokenType) string {
 	// From query.
-	query := r.URL.Query().Get("jwt")
-	if query != "" {
-		return query
+	// Deprecated: token leaks to logs and referrers, use signed url instead.
+	if api.allowQueryJWT {
+		if query := r.URL.Query().Get("jwt"); query != "" {
+			return query
+		}
 	}
 
 	// From header.
@@ -35,7 +51,15 @@ func (api *API) getJWTFromRequest(r *http.Request) string {
 	}
 
 	// From cookie.
-	cookie, err := r.Cookie("jwt")
+	name := cookieAccess
+	switch tokenType {
+	case tokenRefresh:
+		name = cookieRefresh
+	case tokenChallenge:
+		return ""
+	}
+
+	cookie, err := r.Cookie(name)
 	if err != nil {
 		return ""
 	}
@@ -43,14 +67,64 @@ func (api *API) getJWTFromRequest(r *http.Request) string {
 	return cookie.Value
 }
 
+func (api *API) getAPIKeyFromRequest(r *http.Request) string {
+	key := r.Header.Get("Authorization")
+	if len(key) > 7 && strings.ToUpper(key[0:7]) == "APIKEY " {
+		return key[7:]
+	}
+	return ""
+}
+
+// apiKeyAuth to accept either personal api key
+// with the required scope or jwt access token.
+func (api *API) apiKeyAuth(next http.HandlerFunc, scope string) http.HandlerFunc {
+	jwtNext := api.jwtAuth(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := api.getAPIKeyFromRequest(r)
+		if apiKey == "" {
+			jwtNext(w, r)
+			return
+		}
+
+		claims, code, err := api.service.ValidateAPIKey(r.Context(), apiKey, scope)
+		if err != nil {
+			utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ctxJWTClaim{}, claims)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// adminAuth to only allow admin with jwt access token.
+func (api *API) adminAuth(next http.HandlerFunc) http.HandlerFunc {
+	return api.jwtAuth(func(w http.ResponseWriter, r *http.Request) {
+		claims, code, err := api.getJWTClaimFromContext(r.Context())
+		if err != nil {
+			utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+			return
+		}
+
+		if code, err := api.service.ValidateAdmin(r.Context(), claims.UserID); err != nil {
+			utils.ResponseWithJSON(w, code, nil, stack.Wrap(r.Context(), err))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (api *API) jwtAuth(next http.HandlerFunc, tokenTypes ...tokenType) http.HandlerFunc {
 	tokenType := tokenAccess
 	if len(tokenTypes) > 0 {
 		tokenType = tokenTypes[0]
 	}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		jwtTokenStr := api.getJWTFromRequest(r)
+	return api.csrf(func(w http.ResponseWriter, r *http.Request) {
+		jwtTokenStr := api.getJWTFromRequest(r, tokenType)
 		if jwtTokenStr == "" {
 			utils.ResponseWithJSON(w, http.StatusUnauthorized, nil, stack.Wrap(r.Context(), errors.ErrRequiredToken))
 			return
@@ -67,6 +141,8 @@ func (api *API) jwtAuth(next http.HandlerFunc, tokenTypes ...tokenType) http.Han
 		switch tokenType {
 		case tokenRefresh:
 			uuid = jwtToken.RefreshUUID
+		case tokenChallenge:
+			uuid = jwtToken.ChallengeUUID
 		default:
 			uuid = jwtToken.AccessUUID
 		}
@@ -80,16 +156,22 @@ func (api *API) jwtAuth(next http.HandlerFunc, tokenTypes ...tokenType) http.Han
 		ctx := context.WithValue(r.Context(), ctxJWTClaim{}, jwtToken)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	}, tokenType)
 }
 
 func (api *API) parseJWT(ctx context.Context, jwtTokenStr string, tokenType tokenType) (*service.JWTClaim, int, error) {
 	token, err := jwt.Parse(jwtTokenStr, func(token *jwt.Token) (interface{}, error) {
+		// Asymmetric key picked by key id.
+		if _, ok := token.Header["kid"]; ok {
+			return api.keys.Keyfunc(token)
+		}
+
+		// Legacy HS256 token.
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.ErrInternalServer
 		}
 		switch tokenType {
-		case tokenAccess:
+		case tokenAccess, tokenChallenge:
 			return []byte(api.accessSecret), nil
 		case tokenRefresh:
 			return []byte(api.refreshSecret), nil
@@ -110,11 +192,22 @@ func (api *API) parseJWT(ctx context.Context, jwtTokenStr string, tokenType toke
 		return nil, http.StatusUnauthorized, stack.Wrap(ctx, errors.ErrInvalidToken)
 	}
 
+	// Tokens may share the same key,
+	// so the type should be checked.
+	if t, ok := claims["type"].(string); ok && t != tokenType.String() {
+		return nil, http.StatusUnauthorized, stack.Wrap(ctx, errors.ErrInvalidToken)
+	}
+	if _, ok := token.Header["kid"]; (ok || tokenType == tokenChallenge) && claims["type"] == nil {
+		return nil, http.StatusUnauthorized, stack.Wrap(ctx, errors.ErrInvalidToken)
+	}
+
 	var t service.JWTClaim
 	userID, _ := claims["user_id"].(float64)
 	t.UserID = int64(userID)
 	t.AccessUUID, _ = claims["access_uuid"].(string)
 	t.RefreshUUID, _ = claims["refresh_uuid"].(string)
+	t.FamilyID, _ = claims["family_id"].(string)
+	t.ChallengeUUID, _ = claims["challenge_uuid"].(string)
 
 	return &t, http.StatusOK, nil
 }
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/rl404/image-randomizer/internal/errors"
+)
+
+func TestParseJWTChallenge(t *testing.T) {
+	api := &API{accessSecret: "access-secret", refreshSecret: "refresh-secret"}
+
+	sign := func(secret string, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	claims := func(tokenType string, exp time.Time) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"challenge_uuid": "challenge",
+			"user_id":        1,
+			"exp":            exp.Unix(),
+		}
+		if tokenType != "" {
+			c["type"] = tokenType
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode int
+		wantErr  error
+	}{
+		{
+			name:     "valid",
+			token:    sign("access-secret", claims("challenge", time.Now().Add(time.Minute))),
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "expired",
+			token:    sign("access-secret", claims("challenge", time.Now().Add(-time.Minute))),
+			wantCode: http.StatusUnauthorized,
+			wantErr:  errors.ErrInvalidToken,
+		},
+		{
+			name:     "access token",
+			token:    sign("access-secret", claims("access", time.Now().Add(time.Minute))),
+			wantCode: http.StatusUnauthorized,
+			wantErr:  errors.ErrInvalidToken,
+		},
+		{
+			name:     "missing type",
+			token:    sign("access-secret", claims("", time.Now().Add(time.Minute))),
+			wantCode: http.StatusUnauthorized,
+			wantErr:  errors.ErrInvalidToken,
+		},
+		{
+			name:     "wrong secret",
+			token:    sign("refresh-secret", claims("challenge", time.Now().Add(time.Minute))),
+			wantCode: http.StatusUnauthorized,
+			wantErr:  errors.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim, code, err := api.parseJWT(context.Background(), tt.token, tokenChallenge)
+			if code != tt.wantCode || !_errors.Is(err, tt.wantErr) {
+				t.Fatalf("code = %d, err = %v, want %d %v", code, err, tt.wantCode, tt.wantErr)
+			}
+
+			if err == nil && (claim.ChallengeUUID != "challenge" || claim.UserID != 1) {
+				t.Errorf("claim = %+v", claim)
+			}
+		})
+	}
+}
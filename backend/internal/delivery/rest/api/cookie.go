@@ -0,0 +1,154 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/service"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+const (
+	cookieAccess  = "jwt"
+	cookieRefresh = "jwt_refresh"
+	cookieCSRF    = "csrf_token"
+	headerCSRF    = "X-CSRF-Token"
+
+	// Binds oidc flow to the browser which started it.
+	cookieOIDCState = "oidc_state"
+	oidcStatePath   = "/oidc/callback"
+
+	// Refresh token is only needed by refresh
+	// endpoint so no need to send it everywhere.
+	refreshCookiePath = "/token/refresh"
+)
+
+// CookieConfig is browser cookie session config.
+type CookieConfig struct {
+	Enabled        bool
+	Domain         string
+	Secure         bool
+	SameSite       http.SameSite
+	AccessExpired  time.Duration
+	RefreshExpired time.Duration
+}
+
+// responseWithToken to write token response and
+// set session cookies if cookie mode is enabled.
+func (api *API) responseWithToken(w http.ResponseWriter, r *http.Request, code int, token *service.Token, err error) {
+	if err == nil {
+		api.setTokenCookies(w, token)
+	}
+	utils.ResponseWithJSON(w, code, token, stack.Wrap(r.Context(), err))
+}
+
+// setTokenCookies to set access, refresh and csrf token cookies.
+func (api *API) setTokenCookies(w http.ResponseWriter, token *service.Token) {
+	// Challenge token and account linking are not session.
+	if !api.cookie.Enabled || token == nil || token.AccessToken == "" {
+		return
+	}
+
+	http.SetCookie(w, api.newCookie(cookieAccess, token.AccessToken, "/", api.cookie.AccessExpired, true))
+	http.SetCookie(w, api.newCookie(cookieRefresh, token.RefreshToken, refreshCookiePath, api.cookie.RefreshExpired, true))
+
+	// Readable by js so it can be sent back in header.
+	http.SetCookie(w, api.newCookie(cookieCSRF, rand.Text(), "/", api.cookie.RefreshExpired, false))
+}
+
+// clearTokenCookies to remove all session cookies.
+func (api *API) clearTokenCookies(w http.ResponseWriter) {
+	if !api.cookie.Enabled {
+		return
+	}
+
+	http.SetCookie(w, api.newCookie(cookieAccess, "", "/", -1, true))
+	http.SetCookie(w, api.newCookie(cookieRefresh, "", refreshCookiePath, -1, true))
+	http.SetCookie(w, api.newCookie(cookieCSRF, "", "/", -1, false))
+}
+
+// setOIDCStateCookie to set oidc state cookie. Always set
+// even if cookie session is disabled. Lax so it is sent
+// when redirected back from oidc provider.
+func (api *API) setOIDCStateCookie(w http.ResponseWriter, url *service.OIDCURL) {
+	c := api.newCookie(cookieOIDCState, url.State, oidcStatePath, time.Until(url.ExpiredAt), true)
+	c.SameSite = http.SameSiteLaxMode
+	http.SetCookie(w, c)
+}
+
+// clearOIDCStateCookie to remove oidc state cookie.
+func (api *API) clearOIDCStateCookie(w http.ResponseWriter) {
+	c := api.newCookie(cookieOIDCState, "", oidcStatePath, -1, true)
+	c.SameSite = http.SameSiteLaxMode
+	http.SetCookie(w, c)
+}
+
+func (api *API) newCookie(name, value, path string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   api.cookie.Domain,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   api.cookie.Secure,
+		HttpOnly: httpOnly,
+		SameSite: api.cookie.SameSite,
+	}
+
+	if maxAge < 0 {
+		c.MaxAge = -1
+		c.Expires = time.Unix(0, 0)
+	}
+
+	return c
+}
+
+// isCookieAuth to check if the request is authenticated
+// using cookie. Same order as getJWTFromRequest.
+func (api *API) isCookieAuth(r *http.Request, tokenType tokenType) bool {
+	if (api.allowQueryJWT && r.URL.Query().Get("jwt") != "") || r.Header.Get("Authorization") != "" {
+		return false
+	}
+
+	name := cookieAccess
+	switch tokenType {
+	case tokenRefresh:
+		name = cookieRefresh
+	case tokenChallenge:
+		return false
+	}
+
+	_, err := r.Cookie(name)
+	return err == nil
+}
+
+// csrf to protect state-changing request authenticated
+// with cookie using double-submit csrf token. Used by
+// jwtAuth so all cookie-authenticated routes are covered.
+func (api *API) csrf(next http.HandlerFunc, tokenType tokenType) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !api.isCookieAuth(r, tokenType) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := r.Header.Get(headerCSRF)
+		cookie, err := r.Cookie(cookieCSRF)
+		if err != nil || header == "" || subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+			utils.ResponseWithJSON(w, http.StatusForbidden, nil, stack.Wrap(r.Context(), errors.ErrInvalidCSRFToken))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// streamLog is log middleware for streamed response.
+// Unlike fairy log middleware, the response body is
+// not kept so large response doesn't fill the memory.
+func streamLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := stack.Init(r.Context())
+		start := time.Now()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		level := utils.InfoLevel
+		switch {
+		case ww.Status() >= http.StatusInternalServerError:
+			level = utils.ErrorLevel
+		case ww.Status() >= http.StatusBadRequest:
+			level = utils.WarnLevel
+		}
+
+		m := map[string]interface{}{
+			"level":    level,
+			"duration": time.Since(start).String(),
+			"method":   r.Method,
+			"path":     r.URL.Path,
+			"raw_path": r.RequestURI,
+			"code":     ww.Status(),
+			"bytes":    ww.BytesWritten(),
+		}
+
+		if errStack := stack.Get(ctx); len(errStack) > 0 {
+			errStack = slices.Clone(errStack)
+			slices.Reverse(errStack)
+			m["error"] = errStack
+		}
+
+		utils.Log(m)
+	})
+}
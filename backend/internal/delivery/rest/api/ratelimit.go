@@ -0,0 +1,23 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/rl404/image-randomizer/internal/service"
+)
+
+// setRateLimitHeader to set RateLimit-* header
+// following ietf ratelimit headers draft.
+func (api *API) setRateLimitHeader(w http.ResponseWriter, limit *service.RateLimit) {
+	if limit == nil {
+		return
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(limit.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(limit.Reset.Seconds()))))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Limit, int(limit.Period.Seconds())))
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// APIKey is entity for personal api key.
+type APIKey struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     []string
+	ExpiredAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/apikey/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"gorm.io/gorm"
+)
+
+// DB contains functions for api key database.
+type DB struct {
+	db *gorm.DB
+}
+
+// New to create new api key database.
+func New(db *gorm.DB) *DB {
+	return &DB{
+		db: db,
+	}
+}
+
+// GetByPrefix to get api key by prefix.
+func (db *DB) GetByPrefix(ctx context.Context, prefix string) (*entity.APIKey, int, error) {
+	var a APIKey
+	if err := db.db.WithContext(ctx).Where("prefix = ?", prefix).Take(&a).Error; err != nil {
+		if _errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, stack.Wrap(ctx, err, errors.ErrNotFoundAPIKey)
+		}
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return a.toEntity(), http.StatusOK, nil
+}
+
+// GetByUserID to get api keys of the user.
+func (db *DB) GetByUserID(ctx context.Context, userID int64) ([]*entity.APIKey, int, error) {
+	var keys []APIKey
+	if err := db.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&keys).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return db.toEntities(keys), http.StatusOK, nil
+}
+
+// Create to create new api key.
+func (db *DB) Create(ctx context.Context, data entity.APIKey) (*entity.APIKey, int, error) {
+	a := db.fromEntity(data)
+	if err := db.db.WithContext(ctx).Create(&a).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return a.toEntity(), http.StatusCreated, nil
+}
+
+// UpdateLastUsedAt to update api key last used time.
+func (db *DB) UpdateLastUsedAt(ctx context.Context, id int64) (int, error) {
+	if err := db.db.WithContext(ctx).Model(&APIKey{}).Where("id = ?", id).Update("last_used_at", time.Now()).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+// Delete to delete api key.
+func (db *DB) Delete(ctx context.Context, data entity.APIKey) (int, error) {
+	query := db.db.WithContext(ctx).Where("id = ? and user_id = ?", data.ID, data.UserID).Delete(&APIKey{})
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundAPIKey)
+	}
+
+	return http.StatusOK, nil
+}
+
+// DeleteByUserID to delete all api keys of the user.
+func (db *DB) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	if err := utils.GetDB(ctx, db.db).Where("user_id = ?", userID).Delete(&APIKey{}).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/apikey/entity"
+)
+
+// APIKey is model for api key table.
+type APIKey struct {
+	ID         int64
+	UserID     int64 `gorm:"index:index_user_id"`
+	Name       string
+	Prefix     string `gorm:"index:unique_prefix,unique"`
+	Hash       string
+	Scopes     string
+	ExpiredAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+func (a *APIKey) toEntity() *entity.APIKey {
+	return &entity.APIKey{
+		ID:         a.ID,
+		UserID:     a.UserID,
+		Name:       a.Name,
+		Prefix:     a.Prefix,
+		Hash:       a.Hash,
+		Scopes:     strings.Fields(a.Scopes),
+		ExpiredAt:  a.ExpiredAt,
+		LastUsedAt: a.LastUsedAt,
+		CreatedAt:  a.CreatedAt,
+	}
+}
+
+func (db *DB) toEntities(data []APIKey) []*entity.APIKey {
+	keys := make([]*entity.APIKey, len(data))
+	for i, k := range data {
+		keys[i] = k.toEntity()
+	}
+	return keys
+}
+
+func (db *DB) fromEntity(a entity.APIKey) APIKey {
+	return APIKey{
+		ID:         a.ID,
+		UserID:     a.UserID,
+		Name:       a.Name,
+		Prefix:     a.Prefix,
+		Hash:       a.Hash,
+		Scopes:     strings.Join(a.Scopes, " "),
+		ExpiredAt:  a.ExpiredAt,
+		LastUsedAt: a.LastUsedAt,
+	}
+}
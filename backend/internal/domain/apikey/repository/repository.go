@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rl404/image-randomizer/internal/domain/apikey/entity"
+)
+
+// Repository contains functions for api key domain.
+type Repository interface {
+	GetByPrefix(ctx context.Context, prefix string) (*entity.APIKey, int, error)
+	GetByUserID(ctx context.Context, userID int64) ([]*entity.APIKey, int, error)
+	Create(ctx context.Context, data entity.APIKey) (*entity.APIKey, int, error)
+	UpdateLastUsedAt(ctx context.Context, id int64) (int, error)
+	Delete(ctx context.Context, data entity.APIKey) (int, error)
+	DeleteByUserID(ctx context.Context, userID int64) (int, error)
+}
@@ -0,0 +1,9 @@
+package entity
+
+import "time"
+
+// Attempt is entity for failed attempt.
+type Attempt struct {
+	NextAttemptAt time.Time
+	LockedUntil   time.Time
+}
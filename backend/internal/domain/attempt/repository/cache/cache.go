@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/attempt/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/cache"
+)
+
+type client struct {
+	store cache.Store
+}
+
+// New to create new attempt cache.
+func New(store cache.Store) *client {
+	return &client{
+		store: store,
+	}
+}
+
+// Get to get backoff and lockout of failed attempt.
+// Will return empty attempt if not found.
+func (c *client) Get(ctx context.Context, key string) (*entity.Attempt, int, error) {
+	var data entity.Attempt
+	c.store.Get(ctx, utils.GetKey("attempt", key), &data)
+	return &data, http.StatusOK, nil
+}
+
+// Set to save backoff and lockout of failed attempt.
+func (c *client) Set(ctx context.Context, key string, data entity.Attempt, ttl time.Duration) (int, error) {
+	if err := c.store.Set(ctx, utils.GetKey("attempt", key), data, ttl); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+	return http.StatusOK, nil
+}
+
+// Incr to increment attempt count atomically.
+// Count is forgotten after no increment in ttl.
+// Will return the count after the increment.
+func (c *client) Incr(ctx context.Context, key string, n int64, ttl time.Duration) (int64, int, error) {
+	cnt, err := c.store.Incr(ctx, utils.GetKey("attempt", key, "count"), n, ttl)
+	if err != nil {
+		return 0, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+	return cnt, http.StatusOK, nil
+}
+
+// Delete to delete attempt count, backoff and lockout.
+func (c *client) Delete(ctx context.Context, key string) (int, error) {
+	for _, k := range []string{utils.GetKey("attempt", key), utils.GetKey("attempt", key, "count")} {
+		if err := c.store.Delete(ctx, k); err != nil {
+			return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+		}
+	}
+	return http.StatusOK, nil
+}
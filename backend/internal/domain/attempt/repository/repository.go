@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/attempt/entity"
+)
+
+// Repository contains functions for attempt domain.
+type Repository interface {
+	Get(ctx context.Context, key string) (*entity.Attempt, int, error)
+	Set(ctx context.Context, key string, data entity.Attempt, ttl time.Duration) (int, error)
+	Incr(ctx context.Context, key string, n int64, ttl time.Duration) (int64, int, error)
+	Delete(ctx context.Context, key string) (int, error)
+}
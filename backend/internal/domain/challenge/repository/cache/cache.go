@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/cache"
+)
+
+type client struct {
+	store cache.Store
+}
+
+// New to create new challenge cache.
+func New(store cache.Store) *client {
+	return &client{
+		store: store,
+	}
+}
+
+// Use to mark challenge as used until it is expired.
+// Will return error if already used.
+func (c *client) Use(ctx context.Context, id string, expiredAt time.Time) (int, error) {
+	ok, err := c.store.SetNX(ctx, utils.GetKey("challenge", id), expiredAt, time.Until(expiredAt))
+	if err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	if !ok {
+		return http.StatusBadRequest, stack.Wrap(ctx, errors.ErrUsedChallenge)
+	}
+
+	return http.StatusOK, nil
+}
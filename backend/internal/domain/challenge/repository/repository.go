@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// Repository contains functions for challenge domain.
+type Repository interface {
+	Use(ctx context.Context, id string, expiredAt time.Time) (int, error)
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// Mode is domain policy mode.
+type Mode string
+
+// Available domain policy mode.
+const (
+	// Only image from host not in the rules is allowed.
+	ModeBlocklist Mode = "blocklist"
+	// Only image from host in the rules is allowed.
+	ModeAllowlist Mode = "allowlist"
+)
+
+// Policy is entity for instance-wide image domain policy.
+type Policy struct {
+	Mode     Mode
+	Patterns []string
+}
+
+// IsAllowed to check if the image url is allowed by the policy.
+func (p *Policy) IsAllowed(imageURL string) bool {
+	host := utils.GetURLHost(imageURL)
+	if host == "" {
+		return false
+	}
+
+	matched := utils.MatchHost(p.Patterns, host)
+	if p.Mode == ModeAllowlist {
+		return matched
+	}
+
+	return !matched
+}
+
+// Rule is entity for domain policy rule.
+type Rule struct {
+	ID        int64
+	Pattern   string
+	CreatedAt time.Time
+}
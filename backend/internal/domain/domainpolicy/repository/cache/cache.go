@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/cache"
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/domainpolicy/entity"
+	"github.com/rl404/image-randomizer/internal/domain/domainpolicy/repository"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+type client struct {
+	cacher cache.Cacher
+	loader *utils.CacheLoader
+	repo   repository.Repository
+}
+
+// New to create new domain policy cache.
+func New(cacher cache.Cacher, ttl time.Duration, repo repository.Repository) *client {
+	return &client{
+		cacher: cacher,
+		loader: utils.NewCacheLoader(cacher, ttl),
+		repo:   repo,
+	}
+}
+
+// GetPolicy to get domain policy.
+func (c *client) GetPolicy(ctx context.Context) (*entity.Policy, int, error) {
+	data, code, err := utils.LoadCache(ctx, c.loader, utils.GetKey("domain-policy"), func(ctx context.Context) (*entity.Policy, int, error) {
+		return c.repo.GetPolicy(ctx)
+	})
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+	return data, code, nil
+}
+
+// UpdateMode to update domain policy mode.
+func (c *client) UpdateMode(ctx context.Context, mode entity.Mode) (int, error) {
+	code, err := c.repo.UpdateMode(ctx, mode)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := c.deletePolicy(ctx); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return code, nil
+}
+
+// GetRules to get domain rules.
+func (c *client) GetRules(ctx context.Context) ([]*entity.Rule, int, error) {
+	return c.repo.GetRules(ctx)
+}
+
+// CreateRule to create domain rule.
+func (c *client) CreateRule(ctx context.Context, pattern string) (*entity.Rule, int, error) {
+	rule, code, err := c.repo.CreateRule(ctx, pattern)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := c.deletePolicy(ctx); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return rule, code, nil
+}
+
+// DeleteRule to delete domain rule.
+func (c *client) DeleteRule(ctx context.Context, id int64) (int, error) {
+	code, err := c.repo.DeleteRule(ctx, id)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := c.deletePolicy(ctx); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return code, nil
+}
+
+// deletePolicy to delete cached policy after the write
+// is committed so the old policy is not cached again.
+func (c *client) deletePolicy(ctx context.Context) (int, error) {
+	if err := utils.AfterCommit(ctx, func(ctx context.Context) error {
+		return c.cacher.Delete(ctx, utils.GetKey("domain-policy"))
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/domainpolicy/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DB contains functions for domain policy database.
+type DB struct {
+	db *gorm.DB
+}
+
+// New to create new domain policy database.
+func New(db *gorm.DB) *DB {
+	return &DB{
+		db: db,
+	}
+}
+
+// GetPolicy to get domain policy mode and all rule patterns.
+// Will use blocklist mode if not set yet.
+func (db *DB) GetPolicy(ctx context.Context) (*entity.Policy, int, error) {
+	policy := entity.Policy{
+		Mode:     entity.ModeBlocklist,
+		Patterns: []string{},
+	}
+
+	var p DomainPolicy
+	if err := db.db.WithContext(ctx).Where("id = ?", policyID).Take(&p).Error; err != nil {
+		if !_errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+		}
+	} else {
+		policy.Mode = entity.Mode(p.Mode)
+	}
+
+	if err := db.db.WithContext(ctx).Model(&DomainRule{}).Pluck("pattern", &policy.Patterns).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	return &policy, http.StatusOK, nil
+}
+
+// UpdateMode to update domain policy mode.
+func (db *DB) UpdateMode(ctx context.Context, mode entity.Mode) (int, error) {
+	if err := db.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"mode", "updated_at"}),
+	}).Create(&DomainPolicy{
+		ID:   policyID,
+		Mode: string(mode),
+	}).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+// GetRules to get all domain rules.
+func (db *DB) GetRules(ctx context.Context) ([]*entity.Rule, int, error) {
+	var rules []DomainRule
+	if err := db.db.WithContext(ctx).Order("pattern").Find(&rules).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return db.toRuleEntities(rules), http.StatusOK, nil
+}
+
+// CreateRule to create new domain rule.
+func (db *DB) CreateRule(ctx context.Context, pattern string) (*entity.Rule, int, error) {
+	r := DomainRule{Pattern: pattern}
+
+	query := db.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&r)
+
+	if err := query.Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrDuplicateDomainRule)
+	}
+
+	return r.toEntity(), http.StatusCreated, nil
+}
+
+// DeleteRule to delete domain rule.
+func (db *DB) DeleteRule(ctx context.Context, id int64) (int, error) {
+	query := db.db.WithContext(ctx).Where("id = ?", id).Delete(&DomainRule{})
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundDomainRule)
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/domainpolicy/entity"
+)
+
+// Domain policy is a single row table.
+const policyID = 1
+
+// DomainPolicy is model for domain policy table.
+type DomainPolicy struct {
+	ID        int64 `gorm:"primaryKey;autoIncrement:false"`
+	Mode      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// DomainRule is model for domain rule table.
+type DomainRule struct {
+	ID        int64  `gorm:"primaryKey"`
+	Pattern   string `gorm:"uniqueIndex"`
+	CreatedAt time.Time
+}
+
+func (d *DomainRule) toEntity() *entity.Rule {
+	return &entity.Rule{
+		ID:        d.ID,
+		Pattern:   d.Pattern,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+func (db *DB) toRuleEntities(data []DomainRule) []*entity.Rule {
+	rules := make([]*entity.Rule, len(data))
+	for i, r := range data {
+		rules[i] = r.toEntity()
+	}
+	return rules
+}
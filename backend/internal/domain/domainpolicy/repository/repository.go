@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rl404/image-randomizer/internal/domain/domainpolicy/entity"
+)
+
+// Repository contains functions for domain policy domain.
+type Repository interface {
+	GetPolicy(ctx context.Context) (*entity.Policy, int, error)
+	UpdateMode(ctx context.Context, mode entity.Mode) (int, error)
+	GetRules(ctx context.Context) ([]*entity.Rule, int, error)
+	CreateRule(ctx context.Context, pattern string) (*entity.Rule, int, error)
+	DeleteRule(ctx context.Context, id int64) (int, error)
+}
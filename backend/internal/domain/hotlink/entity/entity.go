@@ -0,0 +1,41 @@
+package entity
+
+import "time"
+
+// MissingRefererPolicy is policy for request
+// without referer and origin.
+type MissingRefererPolicy string
+
+// Available missing referer policy.
+const (
+	MissingRefererAllow    MissingRefererPolicy = "allow"
+	MissingRefererDeny     MissingRefererPolicy = "deny"
+	MissingRefererFallback MissingRefererPolicy = "fallback"
+)
+
+// BlockAction is response for blocked request.
+type BlockAction string
+
+// Available block action.
+const (
+	BlockActionForbidden   BlockAction = "forbidden"
+	BlockActionPlaceholder BlockAction = "placeholder"
+)
+
+// Setting is entity for hotlink protection setting.
+type Setting struct {
+	UserID int64
+	// Empty means hotlink protection is disabled.
+	Allowlist      []string
+	MissingReferer MissingRefererPolicy
+	BlockAction    BlockAction
+	Placeholder    string
+}
+
+// Block is entity for blocked hotlink counter.
+type Block struct {
+	UserID        int64
+	Host          string
+	Count         int64
+	LastBlockedAt time.Time
+}
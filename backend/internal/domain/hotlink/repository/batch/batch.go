@@ -0,0 +1,171 @@
+package batch
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/hotlink/entity"
+	"github.com/rl404/image-randomizer/internal/domain/hotlink/repository"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// Max buffered blocks before flushed, relative to batch size.
+const bufferMultiplier = 10
+
+type blockKey struct {
+	userID int64
+	host   string
+}
+
+// client is hotlink repository which buffers blocked
+// requests and saves the counters in batches so
+// blocking doesn't write to database every request.
+type client struct {
+	repo   repository.Repository
+	size   int
+	blocks chan entity.Block
+	quit   chan struct{}
+	done   chan struct{}
+}
+
+// New to create new hotlink block batch writer. Blocks are
+// flushed when the batch is full or every interval.
+func New(repo repository.Repository, size int, interval time.Duration) *client {
+	c := &client{
+		repo:   repo,
+		size:   size,
+		blocks: make(chan entity.Block, size*bufferMultiplier),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go c.run(interval)
+
+	return c
+}
+
+// Close to stop the batch writer and flush the buffered blocks.
+func (c *client) Close() {
+	close(c.quit)
+	<-c.done
+}
+
+// GetSetting to get user's hotlink setting.
+func (c *client) GetSetting(ctx context.Context, userID int64) (*entity.Setting, int, error) {
+	return c.repo.GetSetting(ctx, userID)
+}
+
+// SaveSetting to save user's hotlink setting.
+func (c *client) SaveSetting(ctx context.Context, data entity.Setting) (int, error) {
+	return c.repo.SaveSetting(ctx, data)
+}
+
+// GetBlocks to get blocked hosts.
+// Buffered blocks are not counted yet.
+func (c *client) GetBlocks(ctx context.Context, userID int64) ([]*entity.Block, int, error) {
+	return c.repo.GetBlocks(ctx, userID)
+}
+
+// AddBlock to queue the blocked counter without waiting
+// for it to be saved. Will be dropped if the buffer is full.
+func (c *client) AddBlock(ctx context.Context, userID int64, host string) (int, error) {
+	select {
+	case c.blocks <- entity.Block{UserID: userID, Host: host, Count: 1, LastBlockedAt: time.Now()}:
+		return http.StatusAccepted, nil
+	default:
+		return http.StatusServiceUnavailable, stack.Wrap(ctx, errors.ErrFullHotlinkBuffer)
+	}
+}
+
+// AddBlocks to increment blocked counters directly.
+func (c *client) AddBlocks(ctx context.Context, data []entity.Block) (int, error) {
+	return c.repo.AddBlocks(ctx, data)
+}
+
+// DeleteByUserID to delete user's hotlink data.
+// Buffered blocks of the user may still be saved.
+func (c *client) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	return c.repo.DeleteByUserID(ctx, userID)
+}
+
+func (c *client) run(interval time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	batch := make(map[blockKey]*entity.Block)
+
+	for {
+		select {
+		case b := <-c.blocks:
+			c.add(batch, b)
+			if len(batch) >= c.size {
+				c.flush(batch)
+			}
+		case <-ticker.C:
+			c.flush(batch)
+		case <-c.quit:
+			for {
+				select {
+				case b := <-c.blocks:
+					c.add(batch, b)
+				default:
+					c.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// add to merge the block to the same user and host counter.
+func (c *client) add(batch map[blockKey]*entity.Block, b entity.Block) {
+	key := blockKey{userID: b.UserID, host: b.Host}
+
+	if old, ok := batch[key]; ok {
+		old.Count += b.Count
+		if b.LastBlockedAt.After(old.LastBlockedAt) {
+			old.LastBlockedAt = b.LastBlockedAt
+		}
+		return
+	}
+
+	batch[key] = &b
+}
+
+// flush to save the counters and clear the batch.
+// Will retry once before the counters are dropped.
+func (c *client) flush(batch map[blockKey]*entity.Block) {
+	if len(batch) == 0 {
+		return
+	}
+
+	blocks := make([]entity.Block, 0, len(batch))
+	for _, b := range batch {
+		blocks = append(blocks, *b)
+	}
+
+	// Same order between instances to avoid deadlock.
+	slices.SortFunc(blocks, func(a, b entity.Block) int {
+		if a.UserID != b.UserID {
+			return cmp.Compare(a.UserID, b.UserID)
+		}
+		return strings.Compare(a.Host, b.Host)
+	})
+
+	ctx := context.Background()
+	if _, err := c.repo.AddBlocks(ctx, blocks); err != nil {
+		if _, err := c.repo.AddBlocks(ctx, blocks); err != nil {
+			utils.Error(stack.Wrap(ctx, err).Error())
+		}
+	}
+
+	clear(batch)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/cache"
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/hotlink/entity"
+	"github.com/rl404/image-randomizer/internal/domain/hotlink/repository"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+type client struct {
+	cacher cache.Cacher
+	loader *utils.CacheLoader
+	repo   repository.Repository
+}
+
+// New to create new hotlink cache.
+func New(cacher cache.Cacher, ttl time.Duration, repo repository.Repository) *client {
+	return &client{
+		cacher: cacher,
+		loader: utils.NewCacheLoader(cacher, ttl),
+		repo:   repo,
+	}
+}
+
+// GetSetting to get user's hotlink setting.
+func (c *client) GetSetting(ctx context.Context, userID int64) (*entity.Setting, int, error) {
+	data, code, err := utils.LoadCache(ctx, c.loader, utils.GetKey("hotlink", "user_id", userID), func(ctx context.Context) (*entity.Setting, int, error) {
+		return c.repo.GetSetting(ctx, userID)
+	})
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+	return data, code, nil
+}
+
+// SaveSetting to save user's hotlink setting.
+func (c *client) SaveSetting(ctx context.Context, data entity.Setting) (int, error) {
+	code, err := c.repo.SaveSetting(ctx, data)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	// Invalidate after committed so the
+	// old data is not cached again.
+	if err := utils.AfterCommit(ctx, func(ctx context.Context) error {
+		return c.cacher.Delete(ctx, utils.GetKey("hotlink", "user_id", data.UserID))
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return code, nil
+}
+
+// GetBlocks to get blocked hosts.
+func (c *client) GetBlocks(ctx context.Context, userID int64) ([]*entity.Block, int, error) {
+	return c.repo.GetBlocks(ctx, userID)
+}
+
+// AddBlock to increment blocked counter.
+func (c *client) AddBlock(ctx context.Context, userID int64, host string) (int, error) {
+	return c.repo.AddBlock(ctx, userID, host)
+}
+
+// AddBlocks to increment blocked counters.
+func (c *client) AddBlocks(ctx context.Context, data []entity.Block) (int, error) {
+	return c.repo.AddBlocks(ctx, data)
+}
+
+// DeleteByUserID to delete user's hotlink data.
+func (c *client) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	code, err := c.repo.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	// Invalidate after committed so the
+	// deleted data is not cached again.
+	if err := utils.AfterCommit(ctx, func(ctx context.Context) error {
+		return c.cacher.Delete(ctx, utils.GetKey("hotlink", "user_id", userID))
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return code, nil
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/hotlink/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Max blocked hosts shown to user.
+const blockLimit = 100
+
+// DB contains functions for hotlink database.
+type DB struct {
+	db *gorm.DB
+}
+
+// New to create new hotlink database.
+func New(db *gorm.DB) *DB {
+	return &DB{
+		db: db,
+	}
+}
+
+// GetSetting to get user's hotlink setting.
+// Will return default setting if not set yet.
+func (db *DB) GetSetting(ctx context.Context, userID int64) (*entity.Setting, int, error) {
+	var s HotlinkSetting
+	if err := db.db.WithContext(ctx).Where("user_id = ?", userID).Take(&s).Error; err != nil {
+		if _errors.Is(err, gorm.ErrRecordNotFound) {
+			return &entity.Setting{
+				UserID:         userID,
+				Allowlist:      []string{},
+				MissingReferer: entity.MissingRefererAllow,
+				BlockAction:    entity.BlockActionForbidden,
+			}, http.StatusOK, nil
+		}
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return s.toEntity(), http.StatusOK, nil
+}
+
+// SaveSetting to create or update user's hotlink setting.
+func (db *DB) SaveSetting(ctx context.Context, data entity.Setting) (int, error) {
+	s := db.fromEntity(data)
+	if err := db.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"allowlist", "missing_referer", "block_action", "placeholder", "updated_at"}),
+	}).Create(&s).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+// GetBlocks to get most blocked hosts.
+func (db *DB) GetBlocks(ctx context.Context, userID int64) ([]*entity.Block, int, error) {
+	var blocks []HotlinkBlock
+	if err := db.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("count desc").
+		Limit(blockLimit).
+		Find(&blocks).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return db.toBlockEntities(blocks), http.StatusOK, nil
+}
+
+// AddBlock to increment blocked counter of the host.
+func (db *DB) AddBlock(ctx context.Context, userID int64, host string) (int, error) {
+	return db.AddBlocks(ctx, []entity.Block{{
+		UserID:        userID,
+		Host:          host,
+		Count:         1,
+		LastBlockedAt: time.Now(),
+	}})
+}
+
+// AddBlocks to increment blocked counters of the hosts.
+// User id and host should be unique in the data.
+func (db *DB) AddBlocks(ctx context.Context, data []entity.Block) (int, error) {
+	if len(data) == 0 {
+		return http.StatusOK, nil
+	}
+
+	blocks := make([]HotlinkBlock, len(data))
+	for i, b := range data {
+		blocks[i] = HotlinkBlock{
+			UserID:        b.UserID,
+			Host:          b.Host,
+			Count:         b.Count,
+			LastBlockedAt: b.LastBlockedAt,
+		}
+	}
+
+	if err := db.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "host"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":           gorm.Expr("? + excluded.count", clause.Column{Table: clause.CurrentTable, Name: "count"}),
+			"last_blocked_at": gorm.Expr("greatest(?, excluded.last_blocked_at)", clause.Column{Table: clause.CurrentTable, Name: "last_blocked_at"}),
+		}),
+	}).Create(&blocks).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	return http.StatusOK, nil
+}
+
+// DeleteByUserID to delete user's hotlink setting and counters.
+func (db *DB) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	if err := utils.GetDB(ctx, db.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&HotlinkBlock{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&HotlinkSetting{}).Error
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/hotlink/entity"
+	"github.com/rl404/image-randomizer/internal/utils/dbtest"
+)
+
+func TestAddBlocksUpsertTable(t *testing.T) {
+	gdb, rec := dbtest.DryRun(t)
+
+	if _, err := New(gdb).AddBlock(context.Background(), 1, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	sqls := rec.SQL(`INSERT INTO "hotlink_block"`)
+	if len(sqls) != 1 {
+		t.Fatalf("got %d queries, want 1", len(sqls))
+	}
+
+	for _, want := range []string{
+		`"hotlink_block"."count" + excluded.count`,
+		`greatest("hotlink_block"."last_blocked_at", excluded.last_blocked_at)`,
+	} {
+		if !strings.Contains(sqls[0], want) {
+			t.Errorf("sql = %s, want containing %s", sqls[0], want)
+		}
+	}
+}
+
+func TestAddBlocksCounts(t *testing.T) {
+	gdb := dbtest.Open(t, &HotlinkBlock{})
+	db := New(gdb)
+	ctx := context.Background()
+
+	first := time.Now().Add(-time.Hour).Truncate(time.Second)
+	last := first.Add(time.Minute)
+
+	// Older flush must not move last blocked time back.
+	for _, at := range []time.Time{first, last, first} {
+		if _, err := db.AddBlocks(ctx, []entity.Block{
+			{UserID: 1, Host: "a.com", Count: 2, LastBlockedAt: at},
+			{UserID: 1, Host: "b.com", Count: 1, LastBlockedAt: at},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blocks, _, err := db.GetBlocks(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int64{"a.com": 6, "b.com": 3}
+	if len(blocks) != len(want) {
+		t.Fatalf("blocks = %d, want %d", len(blocks), len(want))
+	}
+
+	for _, b := range blocks {
+		if b.Count != want[b.Host] {
+			t.Errorf("%s count = %d, want %d", b.Host, b.Count, want[b.Host])
+		}
+		if !b.LastBlockedAt.Equal(last) {
+			t.Errorf("%s last blocked at = %v, want %v", b.Host, b.LastBlockedAt, last)
+		}
+	}
+}
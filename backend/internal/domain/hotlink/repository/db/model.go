@@ -0,0 +1,64 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/hotlink/entity"
+)
+
+// HotlinkSetting is model for hotlink setting table.
+type HotlinkSetting struct {
+	UserID         int64 `gorm:"primaryKey;autoIncrement:false"`
+	Allowlist      string
+	MissingReferer string
+	BlockAction    string
+	Placeholder    string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (h *HotlinkSetting) toEntity() *entity.Setting {
+	return &entity.Setting{
+		UserID:         h.UserID,
+		Allowlist:      strings.Fields(h.Allowlist),
+		MissingReferer: entity.MissingRefererPolicy(h.MissingReferer),
+		BlockAction:    entity.BlockAction(h.BlockAction),
+		Placeholder:    h.Placeholder,
+	}
+}
+
+func (db *DB) fromEntity(s entity.Setting) HotlinkSetting {
+	return HotlinkSetting{
+		UserID:         s.UserID,
+		Allowlist:      strings.Join(s.Allowlist, " "),
+		MissingReferer: string(s.MissingReferer),
+		BlockAction:    string(s.BlockAction),
+		Placeholder:    s.Placeholder,
+	}
+}
+
+// HotlinkBlock is model for hotlink block table.
+type HotlinkBlock struct {
+	UserID        int64  `gorm:"primaryKey;autoIncrement:false"`
+	Host          string `gorm:"primaryKey"`
+	Count         int64
+	LastBlockedAt time.Time
+}
+
+func (h *HotlinkBlock) toEntity() *entity.Block {
+	return &entity.Block{
+		UserID:        h.UserID,
+		Host:          h.Host,
+		Count:         h.Count,
+		LastBlockedAt: h.LastBlockedAt,
+	}
+}
+
+func (db *DB) toBlockEntities(data []HotlinkBlock) []*entity.Block {
+	blocks := make([]*entity.Block, len(data))
+	for i, b := range data {
+		blocks[i] = b.toEntity()
+	}
+	return blocks
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rl404/image-randomizer/internal/domain/hotlink/entity"
+)
+
+// Repository contains functions for hotlink domain.
+type Repository interface {
+	GetSetting(ctx context.Context, userID int64) (*entity.Setting, int, error)
+	SaveSetting(ctx context.Context, data entity.Setting) (int, error)
+	GetBlocks(ctx context.Context, userID int64) ([]*entity.Block, int, error)
+	AddBlock(ctx context.Context, userID int64, host string) (int, error)
+	AddBlocks(ctx context.Context, data []entity.Block) (int, error)
+	DeleteByUserID(ctx context.Context, userID int64) (int, error)
+}
@@ -5,6 +5,7 @@ import (
 	_errors "errors"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/rl404/fairy/cache"
 	"github.com/rl404/fairy/errors/stack"
@@ -16,64 +17,108 @@ import (
 
 type client struct {
 	cacher cache.Cacher
+	loader *utils.CacheLoader
 	repo   repository.Repository
 }
 
 // New to create new image cache.
-func New(cacher cache.Cacher, repo repository.Repository) *client {
+func New(cacher cache.Cacher, ttl time.Duration, repo repository.Repository) *client {
 	return &client{
 		cacher: cacher,
+		loader: utils.NewCacheLoader(cacher, ttl),
 		repo:   repo,
 	}
 }
 
 // Get to get image.
-func (c *client) Get(ctx context.Context, userID int64) (data []*entity.Image, code int, err error) {
-	key := utils.GetKey("images", "user_id", userID)
-	if c.cacher.Get(ctx, key, &data) == nil {
-		return data, http.StatusOK, nil
-	}
-
-	data, code, err = c.repo.Get(ctx, userID)
+func (c *client) Get(ctx context.Context, userID int64) ([]*entity.Image, int, error) {
+	data, code, err := utils.LoadCache(ctx, c.loader, utils.GetKey("images", "user_id", userID), func(ctx context.Context) ([]*entity.Image, int, error) {
+		return c.repo.Get(ctx, userID)
+	})
 	if err != nil {
 		return nil, code, stack.Wrap(ctx, err)
 	}
+	return data, code, nil
+}
 
-	if err := c.cacher.Set(ctx, key, data); err != nil {
-		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
-	}
+// Count to count user's images.
+func (c *client) Count(ctx context.Context, userID int64) (int64, int, error) {
+	return c.repo.Count(ctx, userID)
+}
 
-	return data, code, nil
+// GetByID to get image by id.
+func (c *client) GetByID(ctx context.Context, id int64) (*entity.Image, int, error) {
+	return c.repo.GetByID(ctx, id)
+}
+
+// GetAll to get images of all users.
+func (c *client) GetAll(ctx context.Context, afterID int64, limit int) ([]*entity.Image, int, error) {
+	return c.repo.GetAll(ctx, afterID, limit)
 }
 
 // Create to create image.
 func (c *client) Create(ctx context.Context, data entity.Image) (*entity.Image, int, error) {
-	key := utils.GetKey("images", "user_id", data.UserID)
-	if err := c.cacher.Delete(ctx, key); err != nil {
+	img, code, err := c.repo.Create(ctx, data)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if err := c.invalidate(ctx, data.UserID); err != nil {
 		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
 	}
 
-	return c.repo.Create(ctx, data)
+	return img, code, nil
 }
 
 // Update to update image.
 func (c *client) Update(ctx context.Context, data entity.Image) (int, error) {
-	key := utils.GetKey("images", "user_id", data.UserID)
-	if err := c.cacher.Delete(ctx, key); err != nil {
+	code, err := c.repo.Update(ctx, data)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if err := c.invalidate(ctx, data.UserID); err != nil {
 		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
 	}
 
-	return c.repo.Update(ctx, data)
+	return code, nil
 }
 
 // Delete to delete image.
 func (c *client) Delete(ctx context.Context, data entity.Image) (int, error) {
-	key := utils.GetKey("images", "user_id", data.UserID)
-	if err := c.cacher.Delete(ctx, key); err != nil {
+	code, err := c.repo.Delete(ctx, data)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if err := c.invalidate(ctx, data.UserID); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return code, nil
+}
+
+// DeleteByUserID to delete all images of the user.
+func (c *client) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	code, err := c.repo.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if err := c.invalidate(ctx, userID); err != nil {
 		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
 	}
 
-	return c.repo.Delete(ctx, data)
+	return code, nil
+}
+
+// invalidate to delete cached images after the write is
+// committed, so other instances don't cache the old data
+// again by reads before the commit.
+func (c *client) invalidate(ctx context.Context, userID int64) error {
+	return utils.AfterCommit(ctx, func(ctx context.Context) error {
+		return c.cacher.Delete(ctx, utils.GetKey("images", "user_id", userID))
+	})
 }
 
 type errCache struct {
@@ -2,12 +2,14 @@ package db
 
 import (
 	"context"
+	_errors "errors"
 	"io"
 	"net/http"
 
 	"github.com/rl404/fairy/errors/stack"
 	"github.com/rl404/image-randomizer/internal/domain/image/entity"
 	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -32,10 +34,40 @@ func (db *DB) Get(ctx context.Context, userID int64) ([]*entity.Image, int, erro
 	return db.toEntities(images), http.StatusOK, nil
 }
 
+// Count to count user's images.
+func (db *DB) Count(ctx context.Context, userID int64) (int64, int, error) {
+	var cnt int64
+	if err := utils.GetDB(ctx, db.db).Model(&Image{}).Where("user_id = ?", userID).Count(&cnt).Error; err != nil {
+		return 0, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return cnt, http.StatusOK, nil
+}
+
+// GetByID to get image by id.
+func (db *DB) GetByID(ctx context.Context, id int64) (*entity.Image, int, error) {
+	var image Image
+	if err := db.db.WithContext(ctx).Where("id = ?", id).First(&image).Error; err != nil {
+		if _errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, stack.Wrap(ctx, err, errors.ErrNotFoundImage)
+		}
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return image.toEntity(), http.StatusOK, nil
+}
+
+// GetAll to get images of all users ordered by id.
+func (db *DB) GetAll(ctx context.Context, afterID int64, limit int) ([]*entity.Image, int, error) {
+	var images []Image
+	if err := db.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&images).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return db.toEntities(images), http.StatusOK, nil
+}
+
 // Create to create new image.
 func (db *DB) Create(ctx context.Context, data entity.Image) (*entity.Image, int, error) {
 	i := db.fromEntity(data)
-	if err := db.db.WithContext(ctx).Create(&i).Error; err != nil {
+	if err := utils.GetDB(ctx, db.db).Create(&i).Error; err != nil {
 		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
 	}
 	return i.toEntity(), http.StatusCreated, nil
@@ -74,6 +106,14 @@ func (db *DB) Delete(ctx context.Context, data entity.Image) (int, error) {
 	return http.StatusOK, nil
 }
 
+// DeleteByUserID to delete all images of the user.
+func (db *DB) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	if err := utils.GetDB(ctx, db.db).Where("user_id = ?", userID).Delete(&Image{}).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
 // Download is not implemented.
 func (db *DB) Download(ctx context.Context, path string) (io.ReadCloser, int, error) {
 	return nil, 0, nil
@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	_errors "errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,25 +10,36 @@ import (
 
 	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/rl404/fairy/errors/stack"
+	domainpolicyRepository "github.com/rl404/image-randomizer/internal/domain/domainpolicy/repository"
 	"github.com/rl404/image-randomizer/internal/domain/image/entity"
 	"github.com/rl404/image-randomizer/internal/domain/image/repository"
 	"github.com/rl404/image-randomizer/internal/errors"
 )
 
+// maxRedirects is the same as default http client.
+const maxRedirects = 10
+
 type client struct {
-	http *http.Client
-	repo repository.Repository
+	http         *http.Client
+	repo         repository.Repository
+	domainPolicy domainpolicyRepository.Repository
 }
 
-// New to create new http client.
-func New(repo repository.Repository) *client {
-	return &client{
-		http: &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: newrelic.NewRoundTripper(http.DefaultTransport),
-		},
-		repo: repo,
+// New to create new http client. Every redirect
+// is checked against the domain policy.
+func New(repo repository.Repository, domainPolicy domainpolicyRepository.Repository) *client {
+	c := &client{
+		repo:         repo,
+		domainPolicy: domainPolicy,
+	}
+
+	c.http = &http.Client{
+		Timeout:       30 * time.Second,
+		Transport:     newrelic.NewRoundTripper(http.DefaultTransport),
+		CheckRedirect: c.checkRedirect,
 	}
+
+	return c
 }
 
 // Get to get image.
@@ -35,6 +47,21 @@ func (c *client) Get(ctx context.Context, userID int64) ([]*entity.Image, int, e
 	return c.repo.Get(ctx, userID)
 }
 
+// Count to count user's images.
+func (c *client) Count(ctx context.Context, userID int64) (int64, int, error) {
+	return c.repo.Count(ctx, userID)
+}
+
+// GetByID to get image by id.
+func (c *client) GetByID(ctx context.Context, id int64) (*entity.Image, int, error) {
+	return c.repo.GetByID(ctx, id)
+}
+
+// GetAll to get images of all users.
+func (c *client) GetAll(ctx context.Context, afterID int64, limit int) ([]*entity.Image, int, error) {
+	return c.repo.GetAll(ctx, afterID, limit)
+}
+
 // Create to create image.
 func (c *client) Create(ctx context.Context, data entity.Image) (*entity.Image, int, error) {
 	return c.repo.Create(ctx, data)
@@ -50,6 +77,11 @@ func (c *client) Delete(ctx context.Context, data entity.Image) (int, error) {
 	return c.repo.Delete(ctx, data)
 }
 
+// DeleteByUserID to delete all images of the user.
+func (c *client) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	return c.repo.DeleteByUserID(ctx, userID)
+}
+
 // Download to download image.
 func (c *client) Download(ctx context.Context, path string) (io.ReadCloser, int, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
@@ -59,6 +91,9 @@ func (c *client) Download(ctx context.Context, path string) (io.ReadCloser, int,
 
 	resp, err := c.http.Do(req)
 	if err != nil {
+		if _errors.Is(err, errors.ErrBlockedDomain) {
+			return nil, http.StatusForbidden, stack.Wrap(ctx, err, errors.ErrBlockedDomain)
+		}
 		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
 	}
 
@@ -68,3 +103,22 @@ func (c *client) Download(ctx context.Context, path string) (io.ReadCloser, int,
 
 	return resp.Body, http.StatusOK, nil
 }
+
+// checkRedirect to prevent redirect to
+// domain which is not allowed by the policy.
+func (c *client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	policy, _, err := c.domainPolicy.GetPolicy(req.Context())
+	if err != nil {
+		return err
+	}
+
+	if !policy.IsAllowed(req.URL.String()) {
+		return errors.ErrBlockedDomain
+	}
+
+	return nil
+}
@@ -10,8 +10,12 @@ import (
 // Repository contains functions for image domain.
 type Repository interface {
 	Get(ctx context.Context, userID int64) ([]*entity.Image, int, error)
+	Count(ctx context.Context, userID int64) (int64, int, error)
+	GetByID(ctx context.Context, id int64) (*entity.Image, int, error)
+	GetAll(ctx context.Context, afterID int64, limit int) ([]*entity.Image, int, error)
 	Create(ctx context.Context, data entity.Image) (*entity.Image, int, error)
 	Update(ctx context.Context, data entity.Image) (int, error)
 	Delete(ctx context.Context, data entity.Image) (int, error)
+	DeleteByUserID(ctx context.Context, userID int64) (int, error)
 	Download(ctx context.Context, path string) (io.ReadCloser, int, error)
 }
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Invite is entity for registration invite.
+type Invite struct {
+	ID        int64
+	Code      string
+	MaxUses   int
+	Uses      int
+	ExpiredAt *time.Time
+	CreatedAt time.Time
+}
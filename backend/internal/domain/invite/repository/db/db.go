@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/invite/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"gorm.io/gorm"
+)
+
+// DB contains functions for invite database.
+type DB struct {
+	db *gorm.DB
+}
+
+// New to create new invite database.
+func New(db *gorm.DB) *DB {
+	return &DB{
+		db: db,
+	}
+}
+
+// GetAll to get all invites.
+func (db *DB) GetAll(ctx context.Context) ([]*entity.Invite, int, error) {
+	var invites []Invite
+	if err := db.db.WithContext(ctx).Order("created_at desc").Find(&invites).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return db.toEntities(invites), http.StatusOK, nil
+}
+
+// Create to create new invite.
+// Code will be generated if empty.
+func (db *DB) Create(ctx context.Context, data entity.Invite) (*entity.Invite, int, error) {
+	if data.Code == "" {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+		}
+		data.Code = strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+	}
+
+	i := Invite{
+		Code:      data.Code,
+		MaxUses:   data.MaxUses,
+		ExpiredAt: data.ExpiredAt,
+	}
+	if err := db.db.WithContext(ctx).Create(&i).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return i.toEntity(), http.StatusCreated, nil
+}
+
+// Use to use invite code.
+// Will return error if the code is already
+// fully used or expired.
+func (db *DB) Use(ctx context.Context, code string) (int, error) {
+	query := utils.GetDB(ctx, db.db).
+		Model(&Invite{}).
+		Where("code = ? and uses < max_uses and (expired_at is null or expired_at > ?)", code, time.Now()).
+		Update("uses", gorm.Expr("uses + 1"))
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusForbidden, stack.Wrap(ctx, errors.ErrInvalidInvite)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Delete to delete invite.
+func (db *DB) Delete(ctx context.Context, id int64) (int, error) {
+	query := db.db.WithContext(ctx).Where("id = ?", id).Delete(&Invite{})
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundInvite)
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/invite/entity"
+)
+
+// Invite is model for invite table.
+type Invite struct {
+	ID        int64
+	Code      string `gorm:"index:unique_code,unique"`
+	MaxUses   int
+	Uses      int
+	ExpiredAt *time.Time
+	CreatedAt time.Time
+}
+
+func (i *Invite) toEntity() *entity.Invite {
+	return &entity.Invite{
+		ID:        i.ID,
+		Code:      i.Code,
+		MaxUses:   i.MaxUses,
+		Uses:      i.Uses,
+		ExpiredAt: i.ExpiredAt,
+		CreatedAt: i.CreatedAt,
+	}
+}
+
+func (db *DB) toEntities(data []Invite) []*entity.Invite {
+	invites := make([]*entity.Invite, len(data))
+	for i, inv := range data {
+		invites[i] = inv.toEntity()
+	}
+	return invites
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rl404/image-randomizer/internal/domain/invite/entity"
+)
+
+// Repository contains functions for invite domain.
+type Repository interface {
+	GetAll(ctx context.Context) ([]*entity.Invite, int, error)
+	Create(ctx context.Context, data entity.Invite) (*entity.Invite, int, error)
+	Use(ctx context.Context, code string) (int, error)
+	Delete(ctx context.Context, id int64) (int, error)
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// State is entity for pending oidc login.
+type State struct {
+	State        string
+	CodeVerifier string
+	Nonce        string
+	// UserID is set when linking identity
+	// to an existing user.
+	UserID    int64
+	ExpiredAt time.Time
+}
+
+// Identity is entity for user's oidc identity.
+type Identity struct {
+	ID        int64
+	UserID    int64
+	Issuer    string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
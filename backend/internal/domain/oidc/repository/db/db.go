@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/oidc/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DB contains functions for oidc database.
+type DB struct {
+	db *gorm.DB
+}
+
+// New to create new oidc database.
+func New(db *gorm.DB) *DB {
+	return &DB{
+		db: db,
+	}
+}
+
+// CreateState to save pending oidc login state.
+// Expired states are also cleaned up.
+func (db *DB) CreateState(ctx context.Context, data entity.State) (int, error) {
+	if err := db.db.WithContext(ctx).Where("expired_at < ?", time.Now()).Delete(&OIDCState{}).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if err := db.db.WithContext(ctx).Create(&OIDCState{
+		State:        data.State,
+		CodeVerifier: data.CodeVerifier,
+		Nonce:        data.Nonce,
+		UserID:       data.UserID,
+		ExpiredAt:    data.ExpiredAt,
+	}).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	return http.StatusCreated, nil
+}
+
+// ConsumeState to get and delete oidc login state
+// so it can only be used once.
+func (db *DB) ConsumeState(ctx context.Context, state string) (*entity.State, int, error) {
+	var s []OIDCState
+	if err := db.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("state = ?", state).
+		Delete(&s).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if len(s) == 0 || s[0].ExpiredAt.Before(time.Now()) {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidOIDCState)
+	}
+
+	return s[0].toEntity(), http.StatusOK, nil
+}
+
+// GetIdentity to get oidc identity.
+func (db *DB) GetIdentity(ctx context.Context, issuer, subject string) (*entity.Identity, int, error) {
+	var i OIDCIdentity
+	if err := db.db.WithContext(ctx).Where("issuer = ? and subject = ?", issuer, subject).Take(&i).Error; err != nil {
+		if _errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, stack.Wrap(ctx, err, errors.ErrNotLinkedOIDC)
+		}
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return i.toEntity(), http.StatusOK, nil
+}
+
+// CreateIdentity to link oidc identity to user.
+func (db *DB) CreateIdentity(ctx context.Context, data entity.Identity) (*entity.Identity, int, error) {
+	i := OIDCIdentity{
+		UserID:  data.UserID,
+		Issuer:  data.Issuer,
+		Subject: data.Subject,
+		Email:   data.Email,
+	}
+	if err := db.db.WithContext(ctx).Create(&i).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return i.toEntity(), http.StatusCreated, nil
+}
+
+// DeleteIdentityByUserID to unlink all oidc identities of the user.
+func (db *DB) DeleteIdentityByUserID(ctx context.Context, userID int64) (int, error) {
+	if err := utils.GetDB(ctx, db.db).Where("user_id = ?", userID).Delete(&OIDCIdentity{}).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/oidc/entity"
+)
+
+// OIDCState is model for oidc_state table.
+type OIDCState struct {
+	State        string `gorm:"primaryKey"`
+	CodeVerifier string
+	Nonce        string
+	UserID       int64
+	ExpiredAt    time.Time `gorm:"index:index_expired_at"`
+	CreatedAt    time.Time
+}
+
+func (s *OIDCState) toEntity() *entity.State {
+	return &entity.State{
+		State:        s.State,
+		CodeVerifier: s.CodeVerifier,
+		Nonce:        s.Nonce,
+		UserID:       s.UserID,
+		ExpiredAt:    s.ExpiredAt,
+	}
+}
+
+// OIDCIdentity is model for oidc_identity table.
+type OIDCIdentity struct {
+	ID        int64
+	UserID    int64  `gorm:"index:index_user_id"`
+	Issuer    string `gorm:"index:unique_issuer_subject,unique"`
+	Subject   string `gorm:"index:unique_issuer_subject,unique"`
+	Email     string
+	CreatedAt time.Time
+}
+
+func (i *OIDCIdentity) toEntity() *entity.Identity {
+	return &entity.Identity{
+		ID:        i.ID,
+		UserID:    i.UserID,
+		Issuer:    i.Issuer,
+		Subject:   i.Subject,
+		Email:     i.Email,
+		CreatedAt: i.CreatedAt,
+	}
+}
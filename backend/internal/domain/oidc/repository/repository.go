@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rl404/image-randomizer/internal/domain/oidc/entity"
+)
+
+// Repository contains functions for oidc domain.
+type Repository interface {
+	CreateState(ctx context.Context, data entity.State) (int, error)
+	ConsumeState(ctx context.Context, state string) (*entity.State, int, error)
+
+	GetIdentity(ctx context.Context, issuer, subject string) (*entity.Identity, int, error)
+	CreateIdentity(ctx context.Context, data entity.Identity) (*entity.Identity, int, error)
+	DeleteIdentityByUserID(ctx context.Context, userID int64) (int, error)
+}
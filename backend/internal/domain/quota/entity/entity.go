@@ -0,0 +1,9 @@
+package entity
+
+// Quota is entity for user's quota override.
+// Nil limit means using the default quota.
+type Quota struct {
+	UserID       int64
+	ImageLimit   *int64
+	MonthlyBytes *int64
+}
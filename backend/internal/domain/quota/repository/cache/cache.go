@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/cache"
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/quota/entity"
+	"github.com/rl404/image-randomizer/internal/domain/quota/repository"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+type client struct {
+	cacher cache.Cacher
+	loader *utils.CacheLoader
+	repo   repository.Repository
+}
+
+// New to create new quota cache.
+func New(cacher cache.Cacher, ttl time.Duration, repo repository.Repository) *client {
+	return &client{
+		cacher: cacher,
+		loader: utils.NewCacheLoader(cacher, ttl),
+		repo:   repo,
+	}
+}
+
+// GetQuota to get user's quota override.
+func (c *client) GetQuota(ctx context.Context, userID int64) (*entity.Quota, int, error) {
+	data, code, err := utils.LoadCache(ctx, c.loader, utils.GetKey("quota", "user_id", userID), func(ctx context.Context) (*entity.Quota, int, error) {
+		return c.repo.GetQuota(ctx, userID)
+	})
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+	return data, code, nil
+}
+
+// SaveQuota to save user's quota override.
+func (c *client) SaveQuota(ctx context.Context, data entity.Quota) (int, error) {
+	code, err := c.repo.SaveQuota(ctx, data)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	// Invalidate after committed so the
+	// old data is not cached again.
+	if err := utils.AfterCommit(ctx, func(ctx context.Context) error {
+		return c.cacher.Delete(ctx, utils.GetKey("quota", "user_id", data.UserID))
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return code, nil
+}
+
+// GetMonthlyBytes to get user's proxied bytes in the month.
+func (c *client) GetMonthlyBytes(ctx context.Context, userID int64, month string) (int64, int, error) {
+	return c.repo.GetMonthlyBytes(ctx, userID, month)
+}
+
+// AddMonthlyBytes to increment user's proxied bytes in the month.
+func (c *client) AddMonthlyBytes(ctx context.Context, userID int64, month string, bytes int64) (int, error) {
+	return c.repo.AddMonthlyBytes(ctx, userID, month, bytes)
+}
+
+// Lock to lock user's quota.
+func (c *client) Lock(ctx context.Context, userID int64) (int, error) {
+	return c.repo.Lock(ctx, userID)
+}
+
+// DeleteByUserID to delete user's quota data.
+func (c *client) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	code, err := c.repo.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	// Invalidate after committed so the
+	// deleted data is not cached again.
+	if err := utils.AfterCommit(ctx, func(ctx context.Context) error {
+		return c.cacher.Delete(ctx, utils.GetKey("quota", "user_id", userID))
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return code, nil
+}
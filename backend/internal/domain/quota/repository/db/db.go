@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/quota/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DB contains functions for quota database.
+type DB struct {
+	db *gorm.DB
+}
+
+// New to create new quota database.
+func New(db *gorm.DB) *DB {
+	return &DB{
+		db: db,
+	}
+}
+
+// GetQuota to get user's quota override.
+// Will return empty override if not set yet.
+func (db *DB) GetQuota(ctx context.Context, userID int64) (*entity.Quota, int, error) {
+	var q Quota
+	if err := db.db.WithContext(ctx).Where("user_id = ?", userID).Take(&q).Error; err != nil {
+		if _errors.Is(err, gorm.ErrRecordNotFound) {
+			return &entity.Quota{UserID: userID}, http.StatusOK, nil
+		}
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return q.toEntity(), http.StatusOK, nil
+}
+
+// SaveQuota to create or update user's quota override.
+func (db *DB) SaveQuota(ctx context.Context, data entity.Quota) (int, error) {
+	q := db.fromEntity(data)
+	if err := db.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"image_limit", "monthly_bytes", "updated_at"}),
+	}).Create(&q).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+// GetMonthlyBytes to get user's proxied bytes in the month.
+func (db *DB) GetMonthlyBytes(ctx context.Context, userID int64, month string) (int64, int, error) {
+	var u QuotaUsage
+	if err := db.db.WithContext(ctx).Where("user_id = ? and month = ?", userID, month).Take(&u).Error; err != nil {
+		if _errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, http.StatusOK, nil
+		}
+		return 0, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return u.Bytes, http.StatusOK, nil
+}
+
+// AddMonthlyBytes to increment user's proxied bytes in the month.
+func (db *DB) AddMonthlyBytes(ctx context.Context, userID int64, month string, bytes int64) (int, error) {
+	if err := db.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "month"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"bytes":      gorm.Expr("? + excluded.bytes", clause.Column{Table: clause.CurrentTable, Name: "bytes"}),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&QuotaUsage{
+		UserID: userID,
+		Month:  month,
+		Bytes:  bytes,
+	}).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+// Lock to lock user's quota until the transaction
+// in the context ends, so quota checks of every
+// instance wait for each other.
+func (db *DB) Lock(ctx context.Context, userID int64) (int, error) {
+	if err := utils.GetDB(ctx, db.db).Exec("select pg_advisory_xact_lock(?)", userID).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+// DeleteByUserID to delete user's quota override and usage.
+func (db *DB) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	if err := utils.GetDB(ctx, db.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&QuotaUsage{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&Quota{}).Error
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/internal/utils/dbtest"
+)
+
+func TestAddMonthlyBytesUpsertTable(t *testing.T) {
+	gdb, rec := dbtest.DryRun(t)
+
+	if _, err := New(gdb).AddMonthlyBytes(context.Background(), 1, "2026-10", 100); err != nil {
+		t.Fatal(err)
+	}
+
+	sqls := rec.SQL(`INSERT INTO "quota_usage"`)
+	if len(sqls) != 1 {
+		t.Fatalf("got %d queries, want 1", len(sqls))
+	}
+
+	want := `"quota_usage"."bytes" + excluded.bytes`
+	if !strings.Contains(sqls[0], want) {
+		t.Errorf("sql = %s, want containing %s", sqls[0], want)
+	}
+}
+
+func TestAddMonthlyBytes(t *testing.T) {
+	gdb := dbtest.Open(t, &QuotaUsage{})
+	db := New(gdb)
+	ctx := context.Background()
+
+	// Second call upserts the same key.
+	for _, b := range []int64{100, 50} {
+		if _, err := db.AddMonthlyBytes(ctx, 1, "2026-10", b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bytes, _, err := db.GetMonthlyBytes(ctx, 1, "2026-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes != 150 {
+		t.Errorf("bytes = %d, want 150", bytes)
+	}
+}
+
+func TestLock(t *testing.T) {
+	gdb := dbtest.Open(t)
+	db := New(gdb)
+	transactor := utils.NewTransactor(gdb)
+
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		transactor.Transaction(context.Background(), func(ctx context.Context) (int, error) {
+			if code, err := db.Lock(ctx, 1); err != nil {
+				return code, err
+			}
+			close(locked)
+			<-release
+			return http.StatusOK, nil
+		})
+	}()
+	<-locked
+
+	acquired := make(chan struct{})
+	go func() {
+		transactor.Transaction(context.Background(), func(ctx context.Context) (int, error) {
+			code, err := db.Lock(ctx, 1)
+			close(acquired)
+			return code, err
+		})
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held by other transaction")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock not acquired after other transaction ended")
+	}
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/quota/entity"
+)
+
+// Quota is model for quota override table.
+type Quota struct {
+	UserID       int64 `gorm:"primaryKey;autoIncrement:false"`
+	ImageLimit   *int64
+	MonthlyBytes *int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (q *Quota) toEntity() *entity.Quota {
+	return &entity.Quota{
+		UserID:       q.UserID,
+		ImageLimit:   q.ImageLimit,
+		MonthlyBytes: q.MonthlyBytes,
+	}
+}
+
+func (db *DB) fromEntity(q entity.Quota) Quota {
+	return Quota{
+		UserID:       q.UserID,
+		ImageLimit:   q.ImageLimit,
+		MonthlyBytes: q.MonthlyBytes,
+	}
+}
+
+// QuotaUsage is model for monthly proxied bytes table.
+type QuotaUsage struct {
+	UserID int64 `gorm:"primaryKey;autoIncrement:false"`
+	// Format YYYY-MM.
+	Month     string `gorm:"primaryKey"`
+	Bytes     int64
+	UpdatedAt time.Time
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rl404/image-randomizer/internal/domain/quota/entity"
+)
+
+// Repository contains functions for quota domain.
+type Repository interface {
+	GetQuota(ctx context.Context, userID int64) (*entity.Quota, int, error)
+	SaveQuota(ctx context.Context, data entity.Quota) (int, error)
+	GetMonthlyBytes(ctx context.Context, userID int64, month string) (int64, int, error)
+	AddMonthlyBytes(ctx context.Context, userID int64, month string, bytes int64) (int, error)
+	Lock(ctx context.Context, userID int64) (int, error)
+	DeleteByUserID(ctx context.Context, userID int64) (int, error)
+}
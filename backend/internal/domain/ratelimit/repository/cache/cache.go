@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/cache"
+)
+
+type client struct {
+	store cache.Store
+}
+
+// New to create new rate limit cache.
+func New(store cache.Store) *client {
+	return &client{
+		store: store,
+	}
+}
+
+// Incr to increment request count of the window
+// atomically. Will return the count after the
+// increment.
+func (c *client) Incr(ctx context.Context, key string, ttl time.Duration) (int64, int, error) {
+	cnt, err := c.store.Incr(ctx, utils.GetKey("ratelimit", key), 1, ttl)
+	if err != nil {
+		return 0, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+	return cnt, http.StatusOK, nil
+}
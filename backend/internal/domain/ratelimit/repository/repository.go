@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// Repository contains functions for rate limit domain.
+type Repository interface {
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, int, error)
+}
@@ -1,19 +1,54 @@
 package entity
 
+import "time"
+
 // CreateAccessTokenRequest is request model for create access token.
 type CreateAccessTokenRequest struct {
-	UserID     int64
-	AccessUUID string
+	UserID      int64
+	AccessUUID  string
+	RefreshUUID string
+	FamilyID    string
 }
 
 // CreateRefreshTokenRequest is request model for create refresh token.
 type CreateRefreshTokenRequest struct {
 	UserID      int64
 	RefreshUUID string
+	FamilyID    string
+	IP          string
+	UserAgent   string
+}
+
+// CreateChallengeTokenRequest is request model for create
+// two-factor challenge token.
+type CreateChallengeTokenRequest struct {
+	UserID        int64
+	ChallengeUUID string
 }
 
 // Token is entity for token.
 type Token struct {
-	AccessToken  string
-	RefreshToken string
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+}
+
+// TokenDetail is entity for stored token.
+type TokenDetail struct {
+	UUID      string
+	UserID    int64
+	FamilyID  string
+	Rotated   bool
+	RotatedAt *time.Time
+}
+
+// Session is entity for refresh token family.
+type Session struct {
+	ID         string
+	UserID     int64
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiredAt  time.Time
 }
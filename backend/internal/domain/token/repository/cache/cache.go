@@ -5,88 +5,124 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/golang-jwt/jwt"
 	"github.com/rl404/fairy/cache"
 	"github.com/rl404/fairy/errors/stack"
 	"github.com/rl404/image-randomizer/internal/domain/token/entity"
+	"github.com/rl404/image-randomizer/internal/domain/token/repository"
 	"github.com/rl404/image-randomizer/internal/errors"
 	"github.com/rl404/image-randomizer/internal/utils"
 )
 
 type client struct {
 	cacher         cache.Cacher
-	accessSecret   string
+	repo           repository.Repository
 	accessExpired  time.Duration
-	refreshSecret  string
 	refreshExpired time.Duration
 }
 
 // New to create new token cache.
-func New(cacher cache.Cacher,
-	as string, ae time.Duration,
-	rs string, re time.Duration,
-) *client {
+func New(cacher cache.Cacher, repo repository.Repository, ae time.Duration, re time.Duration) *client {
 	return &client{
 		cacher:         cacher,
-		accessSecret:   as,
+		repo:           repo,
 		accessExpired:  ae,
-		refreshSecret:  rs,
 		refreshExpired: re,
 	}
 }
 
 // CreateAccessToken to create new access token.
 func (c *client) CreateAccessToken(ctx context.Context, data entity.CreateAccessTokenRequest) (*entity.Token, int, error) {
+	token, code, err := c.repo.CreateAccessToken(ctx, data)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
 	keyAccess := utils.GetKey("token", data.AccessUUID)
 	if err := c.cacher.Set(ctx, keyAccess, data.UserID, c.accessExpired); err != nil {
 		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
 	}
 
-	accessToken := jwt.New(jwt.SigningMethodHS256)
-	accessClaim := accessToken.Claims.(jwt.MapClaims)
-	accessClaim["authorized"] = true
-	accessClaim["access_uuid"] = data.AccessUUID
-	accessClaim["user_id"] = data.UserID
-	accessClaim["iat"] = time.Now().UTC().Unix()
-	accessClaim["exp"] = time.Now().UTC().Add(c.accessExpired).Unix()
-	accessTokenStr, err := accessToken.SignedString([]byte(c.accessSecret))
-	if err != nil {
-		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err)
-	}
-
-	return &entity.Token{
-		AccessToken: accessTokenStr,
-	}, http.StatusOK, nil
+	return token, code, nil
 }
 
 // CreateRefreshToken to create new refresh token.
 func (c *client) CreateRefreshToken(ctx context.Context, data entity.CreateRefreshTokenRequest) (*entity.Token, int, error) {
+	token, code, err := c.repo.CreateRefreshToken(ctx, data)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
 	keyRefresh := utils.GetKey("token", data.RefreshUUID)
 	if err := c.cacher.Set(ctx, keyRefresh, data.UserID, c.refreshExpired); err != nil {
 		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
 	}
 
-	refreshToken := jwt.New(jwt.SigningMethodHS256)
-	refreshClaim := refreshToken.Claims.(jwt.MapClaims)
-	refreshClaim["authorized"] = true
-	refreshClaim["refresh_uuid"] = data.RefreshUUID
-	refreshClaim["user_id"] = data.UserID
-	refreshClaim["iat"] = time.Now().UTC().Unix()
-	refreshClaim["exp"] = time.Now().UTC().Add(c.refreshExpired).Unix()
-	refreshTokenStr, err := refreshToken.SignedString([]byte(c.refreshSecret))
-	if err != nil {
-		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err)
-	}
+	return token, code, nil
+}
 
-	return &entity.Token{
-		RefreshToken: refreshTokenStr,
-	}, http.StatusOK, nil
+// CreateChallengeToken to create new two-factor challenge token.
+func (c *client) CreateChallengeToken(ctx context.Context, data entity.CreateChallengeTokenRequest) (*entity.Token, int, error) {
+	return c.repo.CreateChallengeToken(ctx, data)
 }
 
 // Get to get token from cache.
 func (c *client) Get(ctx context.Context, token string) (userID int64) {
-	c.cacher.Get(ctx, utils.GetKey("token", token), &userID)
-	return
+	key := utils.GetKey("token", token)
+	if c.cacher.Get(ctx, key, &userID) == nil && userID != 0 {
+		return userID
+	}
+
+	userID = c.repo.Get(ctx, token)
+	if userID == 0 {
+		return 0
+	}
+
+	c.cacher.Set(ctx, key, userID)
+
+	return userID
+}
+
+// GetDetail to get stored token detail.
+func (c *client) GetDetail(ctx context.Context, token string) (*entity.TokenDetail, int, error) {
+	return c.repo.GetDetail(ctx, token)
+}
+
+// GetByFamilyID to get all token uuids in the token family.
+func (c *client) GetByFamilyID(ctx context.Context, familyID string) ([]string, int, error) {
+	return c.repo.GetByFamilyID(ctx, familyID)
+}
+
+// GetByUserID to get all token uuids of the user.
+func (c *client) GetByUserID(ctx context.Context, userID int64) ([]string, int, error) {
+	return c.repo.GetByUserID(ctx, userID)
+}
+
+// Rotate to mark refresh token as rotated.
+func (c *client) Rotate(ctx context.Context, refreshUUID string) (int, error) {
+	if code, err := c.repo.Rotate(ctx, refreshUUID); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if err := c.cacher.Delete(ctx, utils.GetKey("token", refreshUUID)); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return http.StatusOK, nil
+}
+
+// GetSession to get session.
+func (c *client) GetSession(ctx context.Context, familyID string) (*entity.Session, int, error) {
+	return c.repo.GetSession(ctx, familyID)
+}
+
+// GetSessions to get all active sessions of the user.
+func (c *client) GetSessions(ctx context.Context, userID int64) ([]*entity.Session, int, error) {
+	return c.repo.GetSessions(ctx, userID)
+}
+
+// DeleteSession to delete session.
+func (c *client) DeleteSession(ctx context.Context, familyID string) (int, error) {
+	return c.repo.DeleteSession(ctx, familyID)
 }
 
 // Delete to delete token from cache.
@@ -94,5 +130,10 @@ func (c *client) Delete(ctx context.Context, token string) (int, error) {
 	if err := c.cacher.Delete(ctx, utils.GetKey("token", token)); err != nil {
 		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
 	}
-	return http.StatusOK, nil
+	return c.repo.Delete(ctx, token)
+}
+
+// DeleteExpired to delete expired tokens.
+func (c *client) DeleteExpired(ctx context.Context) (int, error) {
+	return c.repo.DeleteExpired(ctx)
 }
@@ -0,0 +1,260 @@
+package db
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/token/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/jwk"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// challengeExpired is two-factor challenge token expiration.
+const challengeExpired = 5 * time.Minute
+
+// DB contains functions for token database.
+type DB struct {
+	db             *gorm.DB
+	keys           *jwk.KeySet
+	accessSecret   string
+	accessExpired  time.Duration
+	refreshSecret  string
+	refreshExpired time.Duration
+}
+
+// New to create new token database.
+func New(db *gorm.DB,
+	keys *jwk.KeySet,
+	as string, ae time.Duration,
+	rs string, re time.Duration,
+) *DB {
+	return &DB{
+		db:             db,
+		keys:           keys,
+		accessSecret:   as,
+		accessExpired:  ae,
+		refreshSecret:  rs,
+		refreshExpired: re,
+	}
+}
+
+// CreateAccessToken to create new access token.
+func (db *DB) CreateAccessToken(ctx context.Context, data entity.CreateAccessTokenRequest) (*entity.Token, int, error) {
+	if err := db.db.WithContext(ctx).Create(&Token{
+		UUID:        data.AccessUUID,
+		FamilyID:    data.FamilyID,
+		RefreshUUID: data.RefreshUUID,
+		UserID:      data.UserID,
+		ExpiredAt:   time.Now().Add(db.accessExpired),
+	}).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	accessTokenStr, err := utils.GenerateJWT(db.keys, db.accessSecret, db.accessExpired, map[string]interface{}{
+		"type":         "access",
+		"access_uuid":  data.AccessUUID,
+		"refresh_uuid": data.RefreshUUID,
+		"family_id":    data.FamilyID,
+		"user_id":      data.UserID,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err)
+	}
+
+	return &entity.Token{
+		AccessToken: accessTokenStr,
+	}, http.StatusOK, nil
+}
+
+// CreateRefreshToken to create new refresh token.
+// The token family session will be created if not exist.
+func (db *DB) CreateRefreshToken(ctx context.Context, data entity.CreateRefreshTokenRequest) (*entity.Token, int, error) {
+	now := time.Now()
+	expiredAt := now.Add(db.refreshExpired)
+
+	if err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&Token{
+			UUID:        data.RefreshUUID,
+			FamilyID:    data.FamilyID,
+			RefreshUUID: data.RefreshUUID,
+			UserID:      data.UserID,
+			ExpiredAt:   expiredAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"ip", "user_agent", "expired_at", "last_used_at"}),
+		}).Create(&Session{
+			ID:         data.FamilyID,
+			UserID:     data.UserID,
+			IP:         data.IP,
+			UserAgent:  data.UserAgent,
+			ExpiredAt:  expiredAt,
+			LastUsedAt: now,
+		}).Error
+	}); err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	refreshTokenStr, err := utils.GenerateJWT(db.keys, db.refreshSecret, db.refreshExpired, map[string]interface{}{
+		"type":         "refresh",
+		"refresh_uuid": data.RefreshUUID,
+		"family_id":    data.FamilyID,
+		"user_id":      data.UserID,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err)
+	}
+
+	return &entity.Token{
+		RefreshToken: refreshTokenStr,
+	}, http.StatusOK, nil
+}
+
+// CreateChallengeToken to create new short-lived two-factor
+// challenge token. Signed with access token key.
+func (db *DB) CreateChallengeToken(ctx context.Context, data entity.CreateChallengeTokenRequest) (*entity.Token, int, error) {
+	if err := db.db.WithContext(ctx).Create(&Token{
+		UUID:      data.ChallengeUUID,
+		UserID:    data.UserID,
+		ExpiredAt: time.Now().Add(challengeExpired),
+	}).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	challengeTokenStr, err := utils.GenerateJWT(db.keys, db.accessSecret, challengeExpired, map[string]interface{}{
+		"type":           "challenge",
+		"challenge_uuid": data.ChallengeUUID,
+		"user_id":        data.UserID,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err)
+	}
+
+	return &entity.Token{
+		ChallengeToken: challengeTokenStr,
+	}, http.StatusOK, nil
+}
+
+// Get to get token user id.
+// Will return 0 if not found, rotated, or already expired.
+func (db *DB) Get(ctx context.Context, token string) int64 {
+	var t Token
+	if err := db.db.WithContext(ctx).Where("uuid = ? and rotated = false and expired_at > ?", token, time.Now()).Take(&t).Error; err != nil {
+		return 0
+	}
+	return t.UserID
+}
+
+// GetDetail to get stored token detail.
+func (db *DB) GetDetail(ctx context.Context, token string) (*entity.TokenDetail, int, error) {
+	var t Token
+	if err := db.db.WithContext(ctx).Where("uuid = ?", token).Take(&t).Error; err != nil {
+		if _errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusUnauthorized, stack.Wrap(ctx, err, errors.ErrInvalidToken)
+		}
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return t.toDetail(), http.StatusOK, nil
+}
+
+// GetByFamilyID to get all token uuids in the token family.
+func (db *DB) GetByFamilyID(ctx context.Context, familyID string) ([]string, int, error) {
+	var uuids []string
+	if err := db.db.WithContext(ctx).Model(&Token{}).Where("family_id = ?", familyID).Pluck("uuid", &uuids).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return uuids, http.StatusOK, nil
+}
+
+// GetByUserID to get all token uuids of the user.
+func (db *DB) GetByUserID(ctx context.Context, userID int64) ([]string, int, error) {
+	var uuids []string
+	if err := db.db.WithContext(ctx).Model(&Token{}).Where("user_id = ?", userID).Pluck("uuid", &uuids).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return uuids, http.StatusOK, nil
+}
+
+// Rotate to mark refresh token as rotated.
+// Will return error if the token is already rotated.
+func (db *DB) Rotate(ctx context.Context, refreshUUID string) (int, error) {
+	query := db.db.WithContext(ctx).
+		Model(&Token{}).
+		Where("uuid = ? and rotated = false", refreshUUID).
+		Updates(map[string]interface{}{
+			"rotated":    true,
+			"rotated_at": time.Now(),
+		})
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusUnauthorized, stack.Wrap(ctx, errors.ErrInvalidToken)
+	}
+
+	return http.StatusOK, nil
+}
+
+// GetSession to get session.
+func (db *DB) GetSession(ctx context.Context, familyID string) (*entity.Session, int, error) {
+	var s Session
+	if err := db.db.WithContext(ctx).Where("id = ? and expired_at > ?", familyID, time.Now()).Take(&s).Error; err != nil {
+		if _errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, stack.Wrap(ctx, err, errors.ErrNotFoundSession)
+		}
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return s.toEntity(), http.StatusOK, nil
+}
+
+// GetSessions to get all active sessions of the user.
+func (db *DB) GetSessions(ctx context.Context, userID int64) ([]*entity.Session, int, error) {
+	var sessions []Session
+	if err := db.db.WithContext(ctx).
+		Where("user_id = ? and expired_at > ?", userID, time.Now()).
+		Order("last_used_at desc").
+		Find(&sessions).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return db.toSessions(sessions), http.StatusOK, nil
+}
+
+// DeleteSession to delete session.
+func (db *DB) DeleteSession(ctx context.Context, familyID string) (int, error) {
+	if err := db.db.WithContext(ctx).Where("id = ?", familyID).Delete(&Session{}).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+// Delete to delete token.
+func (db *DB) Delete(ctx context.Context, token string) (int, error) {
+	if err := db.db.WithContext(ctx).Where("uuid = ?", token).Delete(&Token{}).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+// DeleteExpired to delete expired tokens and sessions.
+func (db *DB) DeleteExpired(ctx context.Context) (int, error) {
+	if err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expired_at <= ?", time.Now()).Delete(&Token{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("expired_at <= ?", time.Now()).Delete(&Session{}).Error
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
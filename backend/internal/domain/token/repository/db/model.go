@@ -0,0 +1,61 @@
+package db
+
+import (
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/token/entity"
+)
+
+// Token is model for token table.
+type Token struct {
+	UUID        string `gorm:"primaryKey"`
+	FamilyID    string `gorm:"index:index_family_id"`
+	RefreshUUID string `gorm:"index:index_refresh_uuid"`
+	UserID      int64  `gorm:"index:index_user_id"`
+	Rotated     bool
+	RotatedAt   *time.Time
+	ExpiredAt   time.Time `gorm:"index:index_expired_at"`
+	CreatedAt   time.Time
+}
+
+func (t *Token) toDetail() *entity.TokenDetail {
+	return &entity.TokenDetail{
+		UUID:      t.UUID,
+		UserID:    t.UserID,
+		FamilyID:  t.FamilyID,
+		Rotated:   t.Rotated,
+		RotatedAt: t.RotatedAt,
+	}
+}
+
+// Session is model for session table.
+// A session is a refresh token family.
+type Session struct {
+	ID         string `gorm:"primaryKey"`
+	UserID     int64  `gorm:"index:index_user_id"`
+	IP         string
+	UserAgent  string
+	ExpiredAt  time.Time `gorm:"index:index_expired_at"`
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+func (s *Session) toEntity() *entity.Session {
+	return &entity.Session{
+		ID:         s.ID,
+		UserID:     s.UserID,
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiredAt:  s.ExpiredAt,
+	}
+}
+
+func (db *DB) toSessions(data []Session) []*entity.Session {
+	sessions := make([]*entity.Session, len(data))
+	for i, s := range data {
+		sessions[i] = s.toEntity()
+	}
+	return sessions
+}
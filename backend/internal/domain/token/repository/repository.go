@@ -10,6 +10,15 @@ import (
 type Repository interface {
 	CreateAccessToken(ctx context.Context, data entity.CreateAccessTokenRequest) (*entity.Token, int, error)
 	CreateRefreshToken(ctx context.Context, data entity.CreateRefreshTokenRequest) (*entity.Token, int, error)
+	CreateChallengeToken(ctx context.Context, data entity.CreateChallengeTokenRequest) (*entity.Token, int, error)
 	Get(ctx context.Context, token string) int64
+	GetDetail(ctx context.Context, token string) (*entity.TokenDetail, int, error)
+	GetByFamilyID(ctx context.Context, familyID string) ([]string, int, error)
+	GetByUserID(ctx context.Context, userID int64) ([]string, int, error)
+	Rotate(ctx context.Context, refreshUUID string) (int, error)
+	GetSession(ctx context.Context, familyID string) (*entity.Session, int, error)
+	GetSessions(ctx context.Context, userID int64) ([]*entity.Session, int, error)
+	DeleteSession(ctx context.Context, familyID string) (int, error)
 	Delete(ctx context.Context, token string) (int, error)
+	DeleteExpired(ctx context.Context) (int, error)
 }
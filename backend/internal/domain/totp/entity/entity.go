@@ -0,0 +1,9 @@
+package entity
+
+// TOTP is entity for user's totp.
+type TOTP struct {
+	UserID       int64
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+}
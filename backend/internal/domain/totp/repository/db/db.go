@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/totp/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DB contains functions for totp database.
+type DB struct {
+	db *gorm.DB
+}
+
+// New to create new totp database.
+func New(db *gorm.DB) *DB {
+	return &DB{
+		db: db,
+	}
+}
+
+// Get to get user's totp.
+func (db *DB) Get(ctx context.Context, userID int64) (*entity.TOTP, int, error) {
+	var t TOTP
+	if err := db.db.WithContext(ctx).Where("user_id = ?", userID).Take(&t).Error; err != nil {
+		if _errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, stack.Wrap(ctx, err, errors.ErrNotFoundTOTP)
+		}
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return t.toEntity(), http.StatusOK, nil
+}
+
+// Save to create or update user's totp.
+func (db *DB) Save(ctx context.Context, data entity.TOTP) (int, error) {
+	if err := db.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "enabled", "last_used_step", "updated_at"}),
+	}).Create(&TOTP{
+		UserID:       data.UserID,
+		Secret:       data.Secret,
+		Enabled:      data.Enabled,
+		LastUsedStep: data.LastUsedStep,
+	}).Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+// Use to mark totp time step as used.
+// Will return error if the step or a later
+// one is already used.
+func (db *DB) Use(ctx context.Context, userID int64, step int64) (int, error) {
+	query := db.db.WithContext(ctx).
+		Model(&TOTP{}).
+		Where("user_id = ? and last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidTOTPCode)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Delete to delete user's totp and recovery codes.
+func (db *DB) Delete(ctx context.Context, userID int64) (int, error) {
+	if err := utils.GetDB(ctx, db.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&TOTP{}).Error
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+// CreateRecoveryCodes to replace user's recovery codes.
+func (db *DB) CreateRecoveryCodes(ctx context.Context, userID int64, hashes []string) (int, error) {
+	codes := make([]RecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		codes[i] = RecoveryCode{
+			UserID: userID,
+			Hash:   hash,
+		}
+	}
+
+	if err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	return http.StatusCreated, nil
+}
+
+// UseRecoveryCode to use and delete recovery code.
+func (db *DB) UseRecoveryCode(ctx context.Context, userID int64, hash string) (int, error) {
+	query := db.db.WithContext(ctx).Where("user_id = ? and hash = ?", userID, hash).Delete(&RecoveryCode{})
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidTOTPCode)
+	}
+
+	return http.StatusOK, nil
+}
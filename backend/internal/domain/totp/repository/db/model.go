@@ -0,0 +1,34 @@
+package db
+
+import (
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/totp/entity"
+)
+
+// TOTP is model for totp table.
+type TOTP struct {
+	UserID       int64 `gorm:"primaryKey;autoIncrement:false"`
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (t *TOTP) toEntity() *entity.TOTP {
+	return &entity.TOTP{
+		UserID:       t.UserID,
+		Secret:       t.Secret,
+		Enabled:      t.Enabled,
+		LastUsedStep: t.LastUsedStep,
+	}
+}
+
+// RecoveryCode is model for recovery_code table.
+type RecoveryCode struct {
+	ID        int64
+	UserID    int64 `gorm:"index:index_user_id"`
+	Hash      string
+	CreatedAt time.Time
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rl404/image-randomizer/internal/domain/totp/entity"
+)
+
+// Repository contains functions for totp domain.
+type Repository interface {
+	Get(ctx context.Context, userID int64) (*entity.TOTP, int, error)
+	Save(ctx context.Context, data entity.TOTP) (int, error)
+	Use(ctx context.Context, userID int64, step int64) (int, error)
+	Delete(ctx context.Context, userID int64) (int, error)
+
+	CreateRecoveryCodes(ctx context.Context, userID int64, hashes []string) (int, error)
+	UseRecoveryCode(ctx context.Context, userID int64, hash string) (int, error)
+}
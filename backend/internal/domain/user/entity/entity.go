@@ -1,9 +1,47 @@
 package entity
 
+import "time"
+
+// Role is user role.
+type Role string
+
+// Available role.
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+// Visibility is random image list visibility.
+type Visibility string
+
+// Available visibility.
+const (
+	// Anyone with the username.
+	VisibilityPublic Visibility = "public"
+	// Anyone with the list token.
+	VisibilityUnlisted Visibility = "unlisted"
+	// Anyone with the signed url.
+	VisibilityPrivate Visibility = "private"
+)
+
 // User is entity for user.
 type User struct {
 	ID           int64
 	Username     string
 	PasswordHash string
 	PasswordSalt string
+	Visibility   Visibility
+	ListToken    string
+	Role         Role
+	Suspended    bool
+	Deleted      bool
+	CreatedAt    time.Time
+}
+
+// GetAllRequest is get all users request model.
+type GetAllRequest struct {
+	// Search by username.
+	Query string
+	Page  int
+	Limit int
 }
@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/rl404/fairy/cache"
 	"github.com/rl404/fairy/errors/stack"
@@ -14,37 +15,139 @@ import (
 
 type client struct {
 	cacher cache.Cacher
+	loader *utils.CacheLoader
 	repo   repository.Repository
 }
 
 // New to create new user cache.
-func New(cacher cache.Cacher, repo repository.Repository) *client {
+func New(cacher cache.Cacher, ttl time.Duration, repo repository.Repository) *client {
 	return &client{
 		cacher: cacher,
+		loader: utils.NewCacheLoader(cacher, ttl),
 		repo:   repo,
 	}
 }
 
 // GetByUsername to get user by username.
-func (c *client) GetByUsername(ctx context.Context, username string) (data *entity.User, code int, err error) {
-	key := utils.GetKey("user", "username", username)
-	if c.cacher.Get(ctx, key, &data) == nil {
-		return data, http.StatusOK, nil
+func (c *client) GetByUsername(ctx context.Context, username string) (*entity.User, int, error) {
+	data, code, err := utils.LoadCache(ctx, c.loader, utils.GetKey("user", "username", username), func(ctx context.Context) (*entity.User, int, error) {
+		return c.repo.GetByUsername(ctx, username)
+	})
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
 	}
+	return data, code, nil
+}
 
-	data, code, err = c.repo.GetByUsername(ctx, username)
+// GetByID to get user by id.
+func (c *client) GetByID(ctx context.Context, id int64) (*entity.User, int, error) {
+	data, code, err := utils.LoadCache(ctx, c.loader, utils.GetKey("user", "id", id), func(ctx context.Context) (*entity.User, int, error) {
+		return c.repo.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+	return data, code, nil
+}
+
+// GetAll to get all users.
+func (c *client) GetAll(ctx context.Context, data entity.GetAllRequest) ([]*entity.User, int64, int, error) {
+	return c.repo.GetAll(ctx, data)
+}
+
+// Create to create new user.
+func (c *client) Create(ctx context.Context, data entity.User) (*entity.User, int, error) {
+	user, code, err := c.repo.Create(ctx, data)
 	if err != nil {
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
-	if err := c.cacher.Set(ctx, key, data); err != nil {
+	// Cached not found user is not used anymore.
+	if err := c.invalidate(ctx, entity.User{ID: user.ID, Username: data.Username}); err != nil {
 		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
 	}
 
-	return data, code, nil
+	return user, code, nil
 }
 
-// Create to create new user.
-func (c *client) Create(ctx context.Context, data entity.User) (*entity.User, int, error) {
-	return c.repo.Create(ctx, data)
+// Update to update user.
+func (c *client) Update(ctx context.Context, data entity.User) (int, error) {
+	code, err := c.repo.Update(ctx, data)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if err := c.invalidate(ctx, data); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return code, nil
+}
+
+// UpdateVisibility to update random image list visibility.
+func (c *client) UpdateVisibility(ctx context.Context, data entity.User) (int, error) {
+	code, err := c.repo.UpdateVisibility(ctx, data)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if err := c.invalidate(ctx, data); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return code, nil
+}
+
+// UpdateRole to update user role.
+func (c *client) UpdateRole(ctx context.Context, data entity.User) (int, error) {
+	code, err := c.repo.UpdateRole(ctx, data)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if err := c.invalidate(ctx, data); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return code, nil
+}
+
+// UpdateSuspended to suspend or unsuspend user.
+func (c *client) UpdateSuspended(ctx context.Context, data entity.User) (int, error) {
+	code, err := c.repo.UpdateSuspended(ctx, data)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if err := c.invalidate(ctx, data); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return code, nil
+}
+
+// Delete to delete user.
+func (c *client) Delete(ctx context.Context, data entity.User) (int, error) {
+	code, err := c.repo.Delete(ctx, data)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if err := c.invalidate(ctx, data); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return code, nil
+}
+
+// invalidate to delete cached user after the write is
+// committed, so the old data is not cached again by
+// reads before the commit.
+func (c *client) invalidate(ctx context.Context, data entity.User) error {
+	return utils.AfterCommit(ctx, func(ctx context.Context) error {
+		if err := c.cacher.Delete(ctx, utils.GetKey("user", "username", data.Username)); err != nil {
+			return err
+		}
+		return c.cacher.Delete(ctx, utils.GetKey("user", "id", data.ID))
+	})
 }
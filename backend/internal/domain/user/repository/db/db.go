@@ -4,10 +4,13 @@ import (
 	"context"
 	_errors "errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/rl404/fairy/errors/stack"
 	"github.com/rl404/image-randomizer/internal/domain/user/entity"
 	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -24,9 +27,10 @@ func New(db *gorm.DB) *DB {
 }
 
 // GetByUsername to get user by username.
+// Including deleted user.
 func (db *DB) GetByUsername(ctx context.Context, username string) (*entity.User, int, error) {
 	var u User
-	if err := db.db.WithContext(ctx).Where("username = ?", username).Take(&u).Error; err != nil {
+	if err := db.db.WithContext(ctx).Unscoped().Where("username = ?", username).Take(&u).Error; err != nil {
 		if _errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, http.StatusNotFound, stack.Wrap(ctx, err, errors.ErrNotFoundUser)
 		}
@@ -35,11 +39,147 @@ func (db *DB) GetByUsername(ctx context.Context, username string) (*entity.User,
 	return u.toEntity(), http.StatusOK, nil
 }
 
+// GetByID to get user by id.
+func (db *DB) GetByID(ctx context.Context, id int64) (*entity.User, int, error) {
+	var u User
+	if err := db.db.WithContext(ctx).Where("id = ?", id).Take(&u).Error; err != nil {
+		if _errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, stack.Wrap(ctx, err, errors.ErrNotFoundUser)
+		}
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return u.toEntity(), http.StatusOK, nil
+}
+
+// GetAll to get all users including deleted user.
+func (db *DB) GetAll(ctx context.Context, data entity.GetAllRequest) ([]*entity.User, int64, int, error) {
+	query := db.db.WithContext(ctx).Model(&User{}).Unscoped()
+
+	if data.Query != "" {
+		query = query.Where("username like ?", "%"+strings.NewReplacer("%", "\\%", "_", "\\_").Replace(data.Query)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	var users []User
+	if err := query.
+		Order("id").
+		Offset((data.Page - 1) * data.Limit).
+		Limit(data.Limit).
+		Find(&users).Error; err != nil {
+		return nil, 0, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	return db.toEntities(users), total, http.StatusOK, nil
+}
+
 // Create to create new user.
 func (db *DB) Create(ctx context.Context, data entity.User) (*entity.User, int, error) {
 	u := db.fromEntity(data)
-	if err := db.db.WithContext(ctx).Create(&u).Error; err != nil {
+	if err := utils.GetDB(ctx, db.db).Create(&u).Error; err != nil {
 		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
 	}
 	return u.toEntity(), http.StatusCreated, nil
 }
+
+// Update to update user.
+func (db *DB) Update(ctx context.Context, data entity.User) (int, error) {
+	query := db.db.WithContext(ctx).
+		Model(&User{}).
+		Where("id = ?", data.ID).
+		Updates(map[string]interface{}{
+			"password_hash": data.PasswordHash,
+			"password_salt": data.PasswordSalt,
+		})
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundUser)
+	}
+
+	return http.StatusOK, nil
+}
+
+// UpdateVisibility to update random image list visibility and token.
+func (db *DB) UpdateVisibility(ctx context.Context, data entity.User) (int, error) {
+	query := db.db.WithContext(ctx).
+		Model(&User{}).
+		Where("id = ?", data.ID).
+		Updates(map[string]interface{}{
+			"visibility": string(data.Visibility),
+			"list_token": data.ListToken,
+		})
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundUser)
+	}
+
+	return http.StatusOK, nil
+}
+
+// UpdateRole to update user role.
+func (db *DB) UpdateRole(ctx context.Context, data entity.User) (int, error) {
+	query := db.db.WithContext(ctx).
+		Model(&User{}).
+		Where("id = ?", data.ID).
+		Update("role", string(data.Role))
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundUser)
+	}
+
+	return http.StatusOK, nil
+}
+
+// UpdateSuspended to suspend or unsuspend user.
+func (db *DB) UpdateSuspended(ctx context.Context, data entity.User) (int, error) {
+	var suspendedAt *time.Time
+	if data.Suspended {
+		now := time.Now()
+		suspendedAt = &now
+	}
+
+	query := db.db.WithContext(ctx).
+		Model(&User{}).
+		Where("id = ?", data.ID).
+		Update("suspended_at", suspendedAt)
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundUser)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Delete to soft-delete user.
+func (db *DB) Delete(ctx context.Context, data entity.User) (int, error) {
+	query := utils.GetDB(ctx, db.db).Where("id = ?", data.ID).Delete(&User{})
+
+	if err := query.Error; err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	if query.RowsAffected == 0 {
+		return http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundUser)
+	}
+
+	return http.StatusOK, nil
+}
@@ -13,6 +13,10 @@ type User struct {
 	Username     string `gorm:"index:unique_username,unique"`
 	PasswordHash string
 	PasswordSalt string
+	Visibility   string `gorm:"type:varchar(10);not null;default:public"`
+	ListToken    string
+	Role         string `gorm:"type:varchar(10);not null;default:user"`
+	SuspendedAt  *time.Time
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    gorm.DeletedAt
@@ -24,14 +28,39 @@ func (u *User) toEntity() *entity.User {
 		Username:     u.Username,
 		PasswordHash: u.PasswordHash,
 		PasswordSalt: u.PasswordSalt,
+		Visibility:   entity.Visibility(u.Visibility),
+		ListToken:    u.ListToken,
+		Role:         entity.Role(u.Role),
+		Suspended:    u.SuspendedAt != nil,
+		Deleted:      u.DeletedAt.Valid,
+		CreatedAt:    u.CreatedAt,
 	}
 }
 
 func (db *DB) fromEntity(u entity.User) User {
+	if u.Visibility == "" {
+		u.Visibility = entity.VisibilityPublic
+	}
+
+	if u.Role == "" {
+		u.Role = entity.RoleUser
+	}
+
 	return User{
 		ID:           u.ID,
 		Username:     u.Username,
 		PasswordHash: u.PasswordHash,
 		PasswordSalt: u.PasswordSalt,
+		Visibility:   string(u.Visibility),
+		ListToken:    u.ListToken,
+		Role:         string(u.Role),
+	}
+}
+
+func (db *DB) toEntities(data []User) []*entity.User {
+	users := make([]*entity.User, len(data))
+	for i, u := range data {
+		users[i] = u.toEntity()
 	}
+	return users
 }
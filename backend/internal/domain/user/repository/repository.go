@@ -9,5 +9,12 @@ import (
 // Repository contains functions for user domain.
 type Repository interface {
 	GetByUsername(ctx context.Context, username string) (*entity.User, int, error)
+	GetByID(ctx context.Context, id int64) (*entity.User, int, error)
+	GetAll(ctx context.Context, data entity.GetAllRequest) ([]*entity.User, int64, int, error)
 	Create(ctx context.Context, data entity.User) (*entity.User, int, error)
+	Update(ctx context.Context, data entity.User) (int, error)
+	UpdateVisibility(ctx context.Context, data entity.User) (int, error)
+	UpdateRole(ctx context.Context, data entity.User) (int, error)
+	UpdateSuspended(ctx context.Context, data entity.User) (int, error)
+	Delete(ctx context.Context, data entity.User) (int, error)
 }
@@ -0,0 +1,68 @@
+package entity
+
+import "time"
+
+// UAClass is coarse user agent class.
+type UAClass string
+
+// Available user agent class.
+const (
+	UAClassDesktop UAClass = "desktop"
+	UAClassMobile  UAClass = "mobile"
+	UAClassBot     UAClass = "bot"
+	UAClassOther   UAClass = "other"
+)
+
+// View is entity for random image hit.
+type View struct {
+	UserID  int64
+	ImageID int64
+	// Empty if the request has no referer and origin.
+	RefererHost string
+	UAClass     UAClass
+	CreatedAt   time.Time
+}
+
+// DailyImageCount is entity for daily view counter per image.
+type DailyImageCount struct {
+	UserID  int64
+	Date    time.Time
+	ImageID int64
+	Count   int64
+}
+
+// ImageCount is entity for view count per image.
+type ImageCount struct {
+	ImageID int64
+	Count   int64
+}
+
+// DailyCount is entity for view count per day.
+type DailyCount struct {
+	Date  time.Time
+	Count int64
+}
+
+// RefererCount is entity for view count per referer host.
+type RefererCount struct {
+	Host  string
+	Count int64
+}
+
+// CountRequest is entity for view count request.
+// From and To are dates in UTC and inclusive.
+type CountRequest struct {
+	UserID int64
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+// ExportRequest is entity for view export request.
+// From and To are dates in UTC and inclusive.
+type ExportRequest struct {
+	// 0 for all users.
+	UserID int64
+	From   time.Time
+	To     time.Time
+}
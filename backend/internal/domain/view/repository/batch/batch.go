@@ -0,0 +1,161 @@
+package batch
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/view/entity"
+	"github.com/rl404/image-randomizer/internal/domain/view/repository"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// Max buffered views before flushed, relative to batch size.
+const bufferMultiplier = 10
+
+// client is view repository which buffers
+// the views and saves them in batches.
+type client struct {
+	repo  repository.Repository
+	size  int
+	views chan entity.View
+	quit  chan struct{}
+	done  chan struct{}
+
+	// Views dropped since last flush.
+	dropped atomic.Int64
+}
+
+// New to create new view batch writer. Views are flushed
+// when the batch is full or every interval.
+func New(repo repository.Repository, size int, interval time.Duration) *client {
+	c := &client{
+		repo:  repo,
+		size:  size,
+		views: make(chan entity.View, size*bufferMultiplier),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	go c.run(interval)
+
+	return c
+}
+
+// Close to stop the batch writer and flush the buffered views.
+func (c *client) Close() {
+	close(c.quit)
+	<-c.done
+}
+
+// Create to queue the views without waiting for them to be saved.
+// Views will be dropped if the buffer is full.
+func (c *client) Create(ctx context.Context, data []entity.View) (int, error) {
+	for i, v := range data {
+		select {
+		case c.views <- v:
+		default:
+			c.dropped.Add(int64(len(data) - i))
+			return http.StatusServiceUnavailable, stack.Wrap(ctx, errors.ErrFullViewBuffer)
+		}
+	}
+	return http.StatusAccepted, nil
+}
+
+// GetImageCounts to get total views per image.
+func (c *client) GetImageCounts(ctx context.Context, data entity.CountRequest) ([]*entity.ImageCount, int, error) {
+	return c.repo.GetImageCounts(ctx, data)
+}
+
+// GetDailyCounts to get total views per day.
+func (c *client) GetDailyCounts(ctx context.Context, data entity.CountRequest) ([]*entity.DailyCount, int, error) {
+	return c.repo.GetDailyCounts(ctx, data)
+}
+
+// GetRefererCounts to get top referer hosts.
+func (c *client) GetRefererCounts(ctx context.Context, data entity.CountRequest) ([]*entity.RefererCount, int, error) {
+	return c.repo.GetRefererCounts(ctx, data)
+}
+
+// ExportViews to iterate saved views.
+func (c *client) ExportViews(ctx context.Context, data entity.ExportRequest, fn func(entity.View) error) (int, error) {
+	return c.repo.ExportViews(ctx, data, fn)
+}
+
+// ExportDailyCounts to iterate saved daily counters.
+func (c *client) ExportDailyCounts(ctx context.Context, data entity.ExportRequest, fn func(entity.DailyImageCount) error) (int, error) {
+	return c.repo.ExportDailyCounts(ctx, data, fn)
+}
+
+// DeleteByUserID to delete user's views.
+// Buffered views of the user may still be saved.
+func (c *client) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	return c.repo.DeleteByUserID(ctx, userID)
+}
+
+func (c *client) run(interval time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	batch := make([]entity.View, 0, c.size)
+
+	for {
+		select {
+		case v := <-c.views:
+			batch = append(batch, v)
+			if len(batch) >= c.size {
+				batch = c.flush(batch)
+			}
+		case <-ticker.C:
+			batch = c.flush(batch)
+		case <-c.quit:
+			for {
+				select {
+				case v := <-c.views:
+					batch = append(batch, v)
+				default:
+					c.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush to save the views and clear the batch.
+// Will retry once before the views are dropped.
+func (c *client) flush(batch []entity.View) []entity.View {
+	c.logDropped()
+
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx := context.Background()
+	if _, err := c.repo.Create(ctx, batch); err != nil {
+		if _, err := c.repo.Create(ctx, batch); err != nil {
+			utils.Error(stack.Wrap(ctx, err).Error())
+			c.dropped.Add(int64(len(batch)))
+			c.logDropped()
+		}
+	}
+
+	return batch[:0]
+}
+
+// logDropped to log the number of dropped views
+// since last time and reset the counter.
+func (c *client) logDropped() {
+	if dropped := c.dropped.Swap(0); dropped > 0 {
+		utils.Log(map[string]interface{}{
+			"level":   utils.WarnLevel,
+			"message": "views are dropped",
+			"dropped": dropped,
+		})
+	}
+}
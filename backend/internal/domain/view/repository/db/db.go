@@ -0,0 +1,251 @@
+package db
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/view/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Rows per insert query.
+const insertBatch = 500
+
+// DB contains functions for view database.
+type DB struct {
+	db *gorm.DB
+}
+
+// New to create new view database.
+func New(db *gorm.DB) *DB {
+	return &DB{
+		db: db,
+	}
+}
+
+// Create to save views and increment the daily counters.
+func (db *DB) Create(ctx context.Context, data []entity.View) (int, error) {
+	if len(data) == 0 {
+		return http.StatusCreated, nil
+	}
+
+	views := make([]View, len(data))
+	counts := make(map[ViewCount]int64)
+	refererCounts := make(map[ViewRefererCount]int64)
+
+	for i, v := range data {
+		views[i] = db.fromEntity(v)
+		date := getDate(v.CreatedAt)
+		counts[ViewCount{UserID: v.UserID, Date: date, ImageID: v.ImageID}]++
+		refererCounts[ViewRefererCount{UserID: v.UserID, Date: date, Host: v.RefererHost}]++
+	}
+
+	viewCounts := make([]ViewCount, 0, len(counts))
+	for c, n := range counts {
+		c.Count = n
+		viewCounts = append(viewCounts, c)
+	}
+
+	viewRefererCounts := make([]ViewRefererCount, 0, len(refererCounts))
+	for c, n := range refererCounts {
+		c.Count = n
+		viewRefererCounts = append(viewRefererCounts, c)
+	}
+
+	if err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(views, insertBatch).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "date"}, {Name: "image_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"count": gorm.Expr("? + excluded.count", clause.Column{Table: clause.CurrentTable, Name: "count"}),
+			}),
+		}).CreateInBatches(viewCounts, insertBatch).Error; err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "date"}, {Name: "host"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"count": gorm.Expr("? + excluded.count", clause.Column{Table: clause.CurrentTable, Name: "count"}),
+			}),
+		}).CreateInBatches(viewRefererCounts, insertBatch).Error
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	return http.StatusCreated, nil
+}
+
+// GetImageCounts to get total views per image.
+func (db *DB) GetImageCounts(ctx context.Context, data entity.CountRequest) ([]*entity.ImageCount, int, error) {
+	var counts []imageCount
+	if err := db.db.WithContext(ctx).
+		Model(&ViewCount{}).
+		Select("image_id, sum(count) as count").
+		Where("user_id = ? and date between ? and ?", data.UserID, getDate(data.From), getDate(data.To)).
+		Group("image_id").
+		Order("count desc").
+		Scan(&counts).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	res := make([]*entity.ImageCount, len(counts))
+	for i, c := range counts {
+		res[i] = &entity.ImageCount{
+			ImageID: c.ImageID,
+			Count:   c.Count,
+		}
+	}
+
+	return res, http.StatusOK, nil
+}
+
+// GetDailyCounts to get total views per day.
+func (db *DB) GetDailyCounts(ctx context.Context, data entity.CountRequest) ([]*entity.DailyCount, int, error) {
+	var counts []dailyCount
+	if err := db.db.WithContext(ctx).
+		Model(&ViewCount{}).
+		Select("date, sum(count) as count").
+		Where("user_id = ? and date between ? and ?", data.UserID, getDate(data.From), getDate(data.To)).
+		Group("date").
+		Order("date").
+		Scan(&counts).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	res := make([]*entity.DailyCount, len(counts))
+	for i, c := range counts {
+		res[i] = &entity.DailyCount{
+			Date:  c.Date,
+			Count: c.Count,
+		}
+	}
+
+	return res, http.StatusOK, nil
+}
+
+// GetRefererCounts to get top referer hosts.
+func (db *DB) GetRefererCounts(ctx context.Context, data entity.CountRequest) ([]*entity.RefererCount, int, error) {
+	var counts []refererCount
+	if err := db.db.WithContext(ctx).
+		Model(&ViewRefererCount{}).
+		Select("host, sum(count) as count").
+		Where("user_id = ? and date between ? and ?", data.UserID, getDate(data.From), getDate(data.To)).
+		Group("host").
+		Order("count desc").
+		Limit(data.Limit).
+		Scan(&counts).Error; err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	res := make([]*entity.RefererCount, len(counts))
+	for i, c := range counts {
+		res[i] = &entity.RefererCount{
+			Host:  c.Host,
+			Count: c.Count,
+		}
+	}
+
+	return res, http.StatusOK, nil
+}
+
+// ExportViews to iterate views ordered by time
+// without loading all of them to memory.
+func (db *DB) ExportViews(ctx context.Context, data entity.ExportRequest, fn func(entity.View) error) (int, error) {
+	query := db.db.WithContext(ctx).
+		Model(&View{}).
+		Where("created_at >= ? and created_at < ?", getDate(data.From), getDate(data.To).AddDate(0, 0, 1)).
+		Order("created_at, id")
+
+	if data.UserID > 0 {
+		query = query.Where("user_id = ?", data.UserID)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v View
+		if err := db.db.ScanRows(rows, &v); err != nil {
+			return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+		}
+
+		if err := fn(v.toEntity()); err != nil {
+			return http.StatusInternalServerError, stack.Wrap(ctx, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	return http.StatusOK, nil
+}
+
+// ExportDailyCounts to iterate daily counters ordered
+// by date without loading all of them to memory.
+func (db *DB) ExportDailyCounts(ctx context.Context, data entity.ExportRequest, fn func(entity.DailyImageCount) error) (int, error) {
+	query := db.db.WithContext(ctx).
+		Model(&ViewCount{}).
+		Where("date between ? and ?", getDate(data.From), getDate(data.To)).
+		Order("date, user_id, image_id")
+
+	if data.UserID > 0 {
+		query = query.Where("user_id = ?", data.UserID)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c ViewCount
+		if err := db.db.ScanRows(rows, &c); err != nil {
+			return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+		}
+
+		if err := fn(c.toEntity()); err != nil {
+			return http.StatusInternalServerError, stack.Wrap(ctx, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+
+	return http.StatusOK, nil
+}
+
+// DeleteByUserID to delete user's views and counters.
+func (db *DB) DeleteByUserID(ctx context.Context, userID int64) (int, error) {
+	if err := utils.GetDB(ctx, db.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&View{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&ViewCount{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&ViewRefererCount{}).Error
+	}); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+	}
+	return http.StatusOK, nil
+}
+
+func getDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/view/entity"
+	"github.com/rl404/image-randomizer/internal/utils/dbtest"
+)
+
+func TestCreateUpsertTable(t *testing.T) {
+	gdb, rec := dbtest.DryRun(t)
+
+	if _, err := New(gdb).Create(context.Background(), []entity.View{{UserID: 1, ImageID: 1, RefererHost: "example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for table, want := range map[string]string{
+		`INSERT INTO "view_count"`:         `"view_count"."count" + excluded.count`,
+		`INSERT INTO "view_referer_count"`: `"view_referer_count"."count" + excluded.count`,
+	} {
+		sqls := rec.SQL(table)
+		if len(sqls) != 1 {
+			t.Fatalf("%s: got %d queries, want 1", table, len(sqls))
+		}
+
+		if !strings.Contains(sqls[0], want) {
+			t.Errorf("%s: sql = %s, want containing %s", table, sqls[0], want)
+		}
+	}
+}
+
+func TestCreateCounts(t *testing.T) {
+	gdb := dbtest.Open(t, &View{}, &ViewCount{}, &ViewRefererCount{})
+	db := New(gdb)
+	ctx := context.Background()
+
+	now := time.Now()
+	views := []entity.View{
+		{UserID: 1, ImageID: 1, RefererHost: "example.com", CreatedAt: now},
+		{UserID: 1, ImageID: 1, RefererHost: "example.com", CreatedAt: now},
+		{UserID: 1, ImageID: 2, RefererHost: "", CreatedAt: now},
+	}
+
+	// Second flush upserts the same keys.
+	for range 2 {
+		if _, err := db.Create(ctx, views); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := entity.CountRequest{UserID: 1, From: now, To: now, Limit: 10}
+
+	images, _, err := db.GetImageCounts(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imageWant := map[int64]int64{1: 4, 2: 2}
+	if len(images) != len(imageWant) {
+		t.Fatalf("image counts = %d, want %d", len(images), len(imageWant))
+	}
+	for _, c := range images {
+		if c.Count != imageWant[c.ImageID] {
+			t.Errorf("image %d count = %d, want %d", c.ImageID, c.Count, imageWant[c.ImageID])
+		}
+	}
+
+	referers, _, err := db.GetRefererCounts(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refererWant := map[string]int64{"example.com": 4, "": 2}
+	if len(referers) != len(refererWant) {
+		t.Fatalf("referer counts = %d, want %d", len(referers), len(refererWant))
+	}
+	for _, c := range referers {
+		if c.Count != refererWant[c.Host] {
+			t.Errorf("referer %q count = %d, want %d", c.Host, c.Count, refererWant[c.Host])
+		}
+	}
+}
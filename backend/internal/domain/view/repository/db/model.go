@@ -0,0 +1,77 @@
+package db
+
+import (
+	"time"
+
+	"github.com/rl404/image-randomizer/internal/domain/view/entity"
+)
+
+// View is model for random image hit table.
+type View struct {
+	ID          int64 `gorm:"primaryKey"`
+	UserID      int64 `gorm:"index:index_view_user_id_created_at"`
+	ImageID     int64
+	RefererHost string
+	UAClass     string
+	CreatedAt   time.Time `gorm:"index:index_view_user_id_created_at;index:index_view_created_at"`
+}
+
+func (v *View) toEntity() entity.View {
+	return entity.View{
+		UserID:      v.UserID,
+		ImageID:     v.ImageID,
+		RefererHost: v.RefererHost,
+		UAClass:     entity.UAClass(v.UAClass),
+		CreatedAt:   v.CreatedAt,
+	}
+}
+
+func (db *DB) fromEntity(v entity.View) View {
+	return View{
+		UserID:      v.UserID,
+		ImageID:     v.ImageID,
+		RefererHost: v.RefererHost,
+		UAClass:     string(v.UAClass),
+		CreatedAt:   v.CreatedAt,
+	}
+}
+
+// ViewCount is model for daily view counter per image table.
+type ViewCount struct {
+	UserID  int64     `gorm:"primaryKey;autoIncrement:false"`
+	Date    time.Time `gorm:"primaryKey;type:date"`
+	ImageID int64     `gorm:"primaryKey;autoIncrement:false"`
+	Count   int64
+}
+
+func (v *ViewCount) toEntity() entity.DailyImageCount {
+	return entity.DailyImageCount{
+		UserID:  v.UserID,
+		Date:    v.Date,
+		ImageID: v.ImageID,
+		Count:   v.Count,
+	}
+}
+
+// ViewRefererCount is model for daily view counter per referer table.
+type ViewRefererCount struct {
+	UserID int64     `gorm:"primaryKey;autoIncrement:false"`
+	Date   time.Time `gorm:"primaryKey;type:date"`
+	Host   string    `gorm:"primaryKey"`
+	Count  int64
+}
+
+type imageCount struct {
+	ImageID int64
+	Count   int64
+}
+
+type dailyCount struct {
+	Date  time.Time
+	Count int64
+}
+
+type refererCount struct {
+	Host  string
+	Count int64
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rl404/image-randomizer/internal/domain/view/entity"
+)
+
+// Repository contains functions for view domain.
+type Repository interface {
+	Create(ctx context.Context, data []entity.View) (int, error)
+	GetImageCounts(ctx context.Context, data entity.CountRequest) ([]*entity.ImageCount, int, error)
+	GetDailyCounts(ctx context.Context, data entity.CountRequest) ([]*entity.DailyCount, int, error)
+	GetRefererCounts(ctx context.Context, data entity.CountRequest) ([]*entity.RefererCount, int, error)
+	ExportViews(ctx context.Context, data entity.ExportRequest, fn func(entity.View) error) (int, error)
+	ExportDailyCounts(ctx context.Context, data entity.ExportRequest, fn func(entity.DailyImageCount) error) (int, error)
+	DeleteByUserID(ctx context.Context, userID int64) (int, error)
+}
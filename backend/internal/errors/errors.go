@@ -4,29 +4,100 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // Error list.
 var (
-	ErrInternalDB           = errors.New("internal database error")
-	ErrInternalCache        = errors.New("internal cache error")
-	ErrInternalServer       = errors.New("internal server error")
-	ErrInvalidDBFormat      = errors.New("invalid db address")
-	ErrInvalidRequestFormat = errors.New("invalid request format")
-	ErrDuplicateUsername    = errors.New("duplicate username")
-	ErrNotFoundUser         = errors.New("user not found")
-	ErrInvalidLogin         = errors.New("wrong username/password")
-	ErrRequiredToken        = errors.New("required token")
-	ErrInvalidToken         = errors.New("invalid token or already expired")
-	ErrNotFoundImage        = errors.New("image not found")
-	ErrInvalidImage         = errors.New("invalid image")
+	ErrInternalDB             = errors.New("internal database error")
+	ErrInternalCache          = errors.New("internal cache error")
+	ErrInternalServer         = errors.New("internal server error")
+	ErrInvalidDBFormat        = errors.New("invalid db address")
+	ErrInvalidRequestFormat   = errors.New("invalid request format")
+	ErrDuplicateUsername      = errors.New("duplicate username")
+	ErrNotFoundUser           = errors.New("user not found")
+	ErrDeletedUser            = errors.New("user has been deleted")
+	ErrInvalidPassword        = errors.New("wrong password")
+	ErrInvalidLogin           = errors.New("wrong username/password")
+	ErrTooManyLoginAttempts   = errors.New("too many failed login attempts, try again later")
+	ErrRequiredToken          = errors.New("required token")
+	ErrInvalidToken           = errors.New("invalid token or already expired")
+	ErrNotFoundSession        = errors.New("session not found")
+	ErrNotFoundImage          = errors.New("image not found")
+	ErrNotFoundAPIKey         = errors.New("api key not found")
+	ErrInvalidAPIKey          = errors.New("invalid api key or already expired")
+	ErrInsufficientScope      = errors.New("insufficient api key scope")
+	ErrOIDCDisabled           = errors.New("oidc login is disabled")
+	ErrInvalidOIDCState       = errors.New("invalid oidc state or already expired")
+	ErrInvalidOIDCToken       = errors.New("invalid oidc id token")
+	ErrNotLinkedOIDC          = errors.New("no user linked to this oidc identity")
+	ErrAlreadyLinkedOIDC      = errors.New("oidc identity already linked to a user")
+	ErrNotFoundTOTP           = errors.New("two-factor authentication is not enabled")
+	ErrAlreadyEnabledTOTP     = errors.New("two-factor authentication is already enabled")
+	ErrInvalidTOTPCode        = errors.New("invalid or already used two-factor code")
+	ErrRegistrationClosed     = errors.New("registration is closed")
+	ErrInvalidInvite          = errors.New("invalid invite code or already used/expired")
+	ErrReservedUsername       = errors.New("username is reserved")
+	ErrNotFoundInvite         = errors.New("invite not found")
+	ErrDisabledChallenge      = errors.New("registration challenge is disabled")
+	ErrInvalidChallenge       = errors.New("invalid registration challenge or nonce")
+	ErrUsedChallenge          = errors.New("registration challenge already used")
+	ErrInvalidCSRFToken       = errors.New("invalid csrf token")
+	ErrInvalidSignedURL       = errors.New("invalid or expired signed url")
+	ErrHotlinkBlocked         = errors.New("hotlink is not allowed")
+	ErrTooManyRequests        = errors.New("too many requests, try again later")
+	ErrSuspendedUser          = errors.New("user has been suspended")
+	ErrNotAdmin               = errors.New("admin only")
+	ErrSuspendAdmin           = errors.New("admin can't be suspended")
+	ErrBlockedDomain          = errors.New("image domain is not allowed")
+	ErrDuplicateDomainRule    = errors.New("duplicate domain rule")
+	ErrNotFoundDomainRule     = errors.New("domain rule not found")
+	ErrImageQuotaExceeded     = errors.New("image quota exceeded, delete some images or ask for higher quota")
+	ErrBandwidthQuotaExceeded = errors.New("monthly bandwidth quota exceeded, try again next month")
+	ErrFullViewBuffer         = errors.New("view buffer is full")
+	ErrFullHotlinkBuffer      = errors.New("hotlink block buffer is full")
+	ErrInvalidDateRange       = errors.New("invalid date range")
+	ErrInvalidImage           = errors.New("invalid image")
 )
 
+// RetryAfterError is error which can be retried
+// after some duration.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+// NewRetryAfterError to create new retry after error.
+func NewRetryAfterError(err error, retryAfter time.Duration) error {
+	return &RetryAfterError{
+		Err:        err,
+		RetryAfter: retryAfter,
+	}
+}
+
+// Error to get error message.
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap to get the original error.
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
 // ErrRequiredField is error for missing field.
 func ErrRequiredField(str string) error {
 	return fmt.Errorf("required field %s", str)
 }
 
+// ErrOIDCProvider is error returned by oidc provider.
+func ErrOIDCProvider(err, desc string) error {
+	if desc == "" {
+		return fmt.Errorf("oidc provider error: %s", err)
+	}
+	return fmt.Errorf("oidc provider error: %s: %s", err, desc)
+}
+
 // ErrGTField is error for greater than field.
 func ErrGTField(str, value string) error {
 	return fmt.Errorf("field %s must be greater than %s", str, value)
@@ -47,11 +118,26 @@ func ErrLTEField(str, value string) error {
 	return fmt.Errorf("field %s must be lower than or equal %s", str, value)
 }
 
+// ErrNEField is error for field which must not equal other field.
+func ErrNEField(str, value string) error {
+	return fmt.Errorf("field %s must not equal %s", str, value)
+}
+
 // ErrURLField is error for url field.
 func ErrURLField(str string) error {
 	return fmt.Errorf("field %s must be in url format", str)
 }
 
+// ErrUsernameField is error for username field.
+func ErrUsernameField(str string) error {
+	return fmt.Errorf("field %s must be 3-32 characters of lowercase letter, number, underscore or dash, and start with letter or number", str)
+}
+
+// ErrHostPatternField is error for host pattern field.
+func ErrHostPatternField(str string) error {
+	return fmt.Errorf("field %s must be a hostname like example.com or *.example.com", str)
+}
+
 // ErrOneOfField is error for oneof field.
 func ErrOneOfField(str, value string) error {
 	return fmt.Errorf("field %s must be one of %s", str, strings.Join(strings.Split(value, " "), "/"))
@@ -4,31 +4,130 @@ import (
 	"context"
 	"io"
 
+	apikeyRepository "github.com/rl404/image-randomizer/internal/domain/apikey/repository"
+	attemptRepository "github.com/rl404/image-randomizer/internal/domain/attempt/repository"
+	challengeRepository "github.com/rl404/image-randomizer/internal/domain/challenge/repository"
+	domainpolicyRepository "github.com/rl404/image-randomizer/internal/domain/domainpolicy/repository"
+	hotlinkRepository "github.com/rl404/image-randomizer/internal/domain/hotlink/repository"
 	imageRepository "github.com/rl404/image-randomizer/internal/domain/image/repository"
+	inviteRepository "github.com/rl404/image-randomizer/internal/domain/invite/repository"
+	oidcRepository "github.com/rl404/image-randomizer/internal/domain/oidc/repository"
+	quotaRepository "github.com/rl404/image-randomizer/internal/domain/quota/repository"
+	ratelimitRepository "github.com/rl404/image-randomizer/internal/domain/ratelimit/repository"
 	tokenRepository "github.com/rl404/image-randomizer/internal/domain/token/repository"
+	totpRepository "github.com/rl404/image-randomizer/internal/domain/totp/repository"
 	userRepository "github.com/rl404/image-randomizer/internal/domain/user/repository"
+	viewRepository "github.com/rl404/image-randomizer/internal/domain/view/repository"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/oidc"
+	"github.com/rl404/image-randomizer/pkg/password"
+	"github.com/rl404/image-randomizer/pkg/pow"
+	"github.com/rl404/image-randomizer/pkg/signedurl"
 )
 
 // Service contains functions for service.
 type Service interface {
 	ValidateToken(ctx context.Context, uuid string, userID int64) (int, error)
-	RefreshToken(ctx context.Context, data JWTClaim) (*Token, int, error)
+	RefreshToken(ctx context.Context, data RefreshTokenRequest) (*Token, int, error)
+	Logout(ctx context.Context, data JWTClaim) (int, error)
+	LogoutAll(ctx context.Context, userID int64) (int, error)
 
+	GetSessions(ctx context.Context, data JWTClaim) ([]Session, int, error)
+	DeleteSession(ctx context.Context, data DeleteSessionRequest) (int, error)
+
+	GetRegisterChallenge(ctx context.Context) (*RegisterChallenge, int, error)
 	Register(ctx context.Context, data RegisterRequest) (*Token, int, error)
 	Login(ctx context.Context, data LoginRequest) (*Token, int, error)
+	Login2FA(ctx context.Context, data Login2FARequest) (*Token, int, error)
+	ChangePassword(ctx context.Context, data ChangePasswordRequest) (int, error)
+	DeleteUser(ctx context.Context, data DeleteUserRequest) (int, error)
+
+	EnrollTOTP(ctx context.Context, userID int64) (*TOTPEnrollment, int, error)
+	ConfirmTOTP(ctx context.Context, data ConfirmTOTPRequest) (*RecoveryCodes, int, error)
+	DisableTOTP(ctx context.Context, data DisableTOTPRequest) (int, error)
+
+	GetAPIKeys(ctx context.Context, userID int64) ([]APIKey, int, error)
+	CreateAPIKey(ctx context.Context, data CreateAPIKeyRequest) (*APIKey, int, error)
+	DeleteAPIKey(ctx context.Context, data DeleteAPIKeyRequest) (int, error)
+	ValidateAPIKey(ctx context.Context, key, scope string) (*JWTClaim, int, error)
+
+	GetOIDCURL(ctx context.Context, userID int64) (*OIDCURL, int, error)
+	OIDCCallback(ctx context.Context, data OIDCCallbackRequest) (*Token, int, error)
 
 	GetImages(ctx context.Context, userID int64) ([]Image, int, error)
 	CreateImage(ctx context.Context, data CreateImageRequest) (*Image, int, error)
 	UpdateImage(ctx context.Context, data UpdateImageRequest) (int, error)
 	DeleteImage(ctx context.Context, data DeleteImageRequest) (int, error)
+	CreateImageURL(ctx context.Context, data CreateImageURLRequest) (*SignedURL, int, error)
+	GetImagePreview(ctx context.Context, data GetImagePreviewRequest) (io.ReadCloser, int, error)
+
+	GetRandomImage(ctx context.Context, data GetRandomImageRequest) (io.ReadCloser, int, error)
+	TakeRandomImageLimit(ctx context.Context, data TakeRandomImageLimitRequest) (*RateLimit, int, error)
+
+	GetList(ctx context.Context, userID int64) (*List, int, error)
+	UpdateList(ctx context.Context, data UpdateListRequest) (*List, int, error)
+	RotateListToken(ctx context.Context, userID int64) (*List, int, error)
+	GetListURL(ctx context.Context, data GetListURLRequest) (*ListURL, int, error)
+
+	GetHotlinkSetting(ctx context.Context, userID int64) (*HotlinkSetting, int, error)
+	UpdateHotlinkSetting(ctx context.Context, data UpdateHotlinkSettingRequest) (*HotlinkSetting, int, error)
+	GetHotlinkBlocks(ctx context.Context, userID int64) ([]HotlinkBlock, int, error)
+
+	GetUsage(ctx context.Context, userID int64) (*Usage, int, error)
+
+	GetStats(ctx context.Context, data GetStatsRequest) (*Stats, int, error)
+	ExportStats(ctx context.Context, data ExportStatsRequest) (*StatsExport, int, error)
+
+	ValidateAdmin(ctx context.Context, userID int64) (int, error)
+	AdminGetUsers(ctx context.Context, data AdminGetUsersRequest) (*AdminUsers, int, error)
+	AdminGetUserImages(ctx context.Context, userID int64) ([]Image, int, error)
+	AdminSuspendUser(ctx context.Context, data AdminSuspendUserRequest) (int, error)
+	AdminLogoutUser(ctx context.Context, userID int64) (int, error)
+	AdminDeleteImage(ctx context.Context, data AdminDeleteImageRequest) (int, error)
+	AdminGetUserUsage(ctx context.Context, userID int64) (*Usage, int, error)
+	AdminUpdateQuota(ctx context.Context, data AdminUpdateQuotaRequest) (*Usage, int, error)
+	AdminGetDomainPolicy(ctx context.Context) (*DomainPolicy, int, error)
+	AdminUpdateDomainMode(ctx context.Context, data AdminUpdateDomainModeRequest) (int, error)
+	AdminCreateDomainRule(ctx context.Context, data AdminCreateDomainRuleRequest) (*DomainRule, int, error)
+	AdminDeleteDomainRule(ctx context.Context, id int64) (int, error)
+	AdminGetInvites(ctx context.Context) ([]Invite, int, error)
+	AdminCreateInvite(ctx context.Context, data AdminCreateInviteRequest) (*Invite, int, error)
+	AdminDeleteInvite(ctx context.Context, id int64) (int, error)
+}
 
-	GetRandomImage(ctx context.Context, username string) (io.ReadCloser, int, error)
+// Config is service config.
+type Config struct {
+	LoginLimit LoginLimitConfig
+	Token      TokenConfig
+	OIDC       OIDCConfig
+	TOTP       TOTPConfig
+	Register   RegisterConfig
+	SignedURL  SignedURLConfig
+	RateLimit  RateLimitConfig
+	Quota      QuotaConfig
 }
 
 type service struct {
-	user  userRepository.Repository
-	image imageRepository.Repository
-	token tokenRepository.Repository
+	user         userRepository.Repository
+	image        imageRepository.Repository
+	token        tokenRepository.Repository
+	apiKey       apikeyRepository.Repository
+	oidc         oidcRepository.Repository
+	totp         totpRepository.Repository
+	invite       inviteRepository.Repository
+	attempt      attemptRepository.Repository
+	challenge    challengeRepository.Repository
+	hotlink      hotlinkRepository.Repository
+	rateLimit    ratelimitRepository.Repository
+	domainPolicy domainpolicyRepository.Repository
+	quota        quotaRepository.Repository
+	view         viewRepository.Repository
+	transactor   *utils.Transactor
+	password     *password.Hasher
+	oidcProvider *oidc.Provider
+	pow          *pow.POW
+	signer       *signedurl.Signer
+	cfg          Config
 }
 
 // Ne to create new service.
@@ -36,10 +135,44 @@ func New(
 	user userRepository.Repository,
 	image imageRepository.Repository,
 	token tokenRepository.Repository,
+	apiKey apikeyRepository.Repository,
+	oidc oidcRepository.Repository,
+	totp totpRepository.Repository,
+	invite inviteRepository.Repository,
+	attempt attemptRepository.Repository,
+	challenge challengeRepository.Repository,
+	hotlink hotlinkRepository.Repository,
+	rateLimit ratelimitRepository.Repository,
+	domainPolicy domainpolicyRepository.Repository,
+	quota quotaRepository.Repository,
+	view viewRepository.Repository,
+	transactor *utils.Transactor,
+	password *password.Hasher,
+	oidcProvider *oidc.Provider,
+	pow *pow.POW,
+	signer *signedurl.Signer,
+	cfg Config,
 ) Service {
 	return &service{
-		user:  user,
-		image: image,
-		token: token,
+		user:         user,
+		image:        image,
+		token:        token,
+		apiKey:       apiKey,
+		oidc:         oidc,
+		totp:         totp,
+		invite:       invite,
+		attempt:      attempt,
+		challenge:    challenge,
+		hotlink:      hotlink,
+		rateLimit:    rateLimit,
+		domainPolicy: domainPolicy,
+		quota:        quota,
+		view:         view,
+		transactor:   transactor,
+		password:     password,
+		oidcProvider: oidcProvider,
+		pow:          pow,
+		signer:       signer,
+		cfg:          cfg,
 	}
 }
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	imageEntity "github.com/rl404/image-randomizer/internal/domain/image/entity"
+	"github.com/rl404/image-randomizer/internal/domain/user/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// ValidateAdmin to check if the user is an active admin.
+func (s *service) ValidateAdmin(ctx context.Context, userID int64) (int, error) {
+	user, code, err := s.user.GetByID(ctx, userID)
+	if err != nil {
+		if code == http.StatusNotFound {
+			return http.StatusForbidden, stack.Wrap(ctx, err, errors.ErrNotAdmin)
+		}
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if user.Role != entity.RoleAdmin || user.Suspended {
+		return http.StatusForbidden, stack.Wrap(ctx, errors.ErrNotAdmin)
+	}
+
+	return http.StatusOK, nil
+}
+
+// AdminUser is user model for admin.
+type AdminUser struct {
+	ID         int64             `json:"id"`
+	Username   string            `json:"username"`
+	Role       entity.Role       `json:"role"`
+	Visibility entity.Visibility `json:"visibility"`
+	Suspended  bool              `json:"suspended"`
+	Deleted    bool              `json:"deleted"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// AdminUsers is paginated user list model for admin.
+type AdminUsers struct {
+	Users []AdminUser `json:"users"`
+	Total int64       `json:"total"`
+}
+
+// AdminGetUsersRequest is admin get users request model.
+type AdminGetUsersRequest struct {
+	Query string `validate:"lte=32" mod:"trim,lcase"`
+	Page  int    `validate:"required,gte=1" mod:"default=1"`
+	Limit int    `validate:"required,gte=1,lte=100" mod:"default=20"`
+}
+
+// AdminGetUsers to list and search users
+// including suspended and deleted users.
+func (s *service) AdminGetUsers(ctx context.Context, data AdminGetUsersRequest) (*AdminUsers, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	users, total, code, err := s.user.GetAll(ctx, entity.GetAllRequest{
+		Query: data.Query,
+		Page:  data.Page,
+		Limit: data.Limit,
+	})
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	res := AdminUsers{
+		Users: make([]AdminUser, len(users)),
+		Total: total,
+	}
+
+	for i, u := range users {
+		res.Users[i] = AdminUser{
+			ID:         u.ID,
+			Username:   u.Username,
+			Role:       u.Role,
+			Visibility: u.Visibility,
+			Suspended:  u.Suspended,
+			Deleted:    u.Deleted,
+			CreatedAt:  u.CreatedAt,
+		}
+	}
+
+	return &res, http.StatusOK, nil
+}
+
+// AdminGetUserImages to get any user's images.
+func (s *service) AdminGetUserImages(ctx context.Context, userID int64) ([]Image, int, error) {
+	if _, code, err := s.user.GetByID(ctx, userID); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	images, code, err := s.GetImages(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return images, http.StatusOK, nil
+}
+
+// AdminSuspendUserRequest is admin suspend user request model.
+type AdminSuspendUserRequest struct {
+	AdminID int64 `validate:"required"`
+	UserID  int64 `validate:"required"`
+	Suspend bool
+}
+
+// AdminSuspendUser to suspend or unsuspend user.
+// Suspended user's sessions will be revoked.
+func (s *service) AdminSuspendUser(ctx context.Context, data AdminSuspendUserRequest) (int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	user, code, err := s.user.GetByID(ctx, data.UserID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	// Admin should be revoked first using cli.
+	if data.Suspend && (user.ID == data.AdminID || user.Role == entity.RoleAdmin) {
+		return http.StatusForbidden, stack.Wrap(ctx, errors.ErrSuspendAdmin)
+	}
+
+	user.Suspended = data.Suspend
+
+	if code, err := s.user.UpdateSuspended(ctx, *user); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if data.Suspend {
+		if code, err := s.LogoutAll(ctx, user.ID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// AdminLogoutUser to revoke all sessions of the user.
+func (s *service) AdminLogoutUser(ctx context.Context, userID int64) (int, error) {
+	if _, code, err := s.user.GetByID(ctx, userID); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.LogoutAll(ctx, userID); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// AdminDeleteImageRequest is admin delete image request model.
+type AdminDeleteImageRequest struct {
+	UserID int64 `validate:"required"`
+	// Set 0 to delete all images of the user.
+	ImageID int64
+}
+
+// AdminDeleteImage to delete user's image or all images.
+// Same as user deleting the image, image quota is freed but
+// recorded views and monthly proxied bytes are kept.
+func (s *service) AdminDeleteImage(ctx context.Context, data AdminDeleteImageRequest) (int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	if data.ImageID == 0 {
+		if code, err := s.image.DeleteByUserID(ctx, data.UserID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+		return http.StatusOK, nil
+	}
+
+	if code, err := s.image.Delete(ctx, imageEntity.Image{
+		ID:     data.ImageID,
+		UserID: data.UserID,
+	}); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return http.StatusOK, nil
+}
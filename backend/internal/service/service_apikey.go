@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/apikey/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// API key scopes.
+const (
+	ScopeImagesRead  = "images:read"
+	ScopeImagesWrite = "images:write"
+)
+
+// API key format is `ir_<prefix>_<secret>`.
+// Prefix is used to find the key, secret is
+// only stored hashed.
+const (
+	apiKeyPrefix       = "ir_"
+	apiKeyPrefixBytes  = 6
+	apiKeySecretBytes  = 32
+	apiKeyLastUsedStep = time.Minute
+)
+
+// APIKey is personal api key model.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Key        string     `json:"key,omitempty"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiredAt  *time.Time `json:"expired_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (s *service) apiKeyFromEntity(data entity.APIKey) APIKey {
+	return APIKey{
+		ID:         data.ID,
+		Name:       data.Name,
+		Prefix:     apiKeyPrefix + data.Prefix,
+		Scopes:     data.Scopes,
+		ExpiredAt:  data.ExpiredAt,
+		LastUsedAt: data.LastUsedAt,
+		CreatedAt:  data.CreatedAt,
+	}
+}
+
+// GetAPIKeys to get user's api keys.
+func (s *service) GetAPIKeys(ctx context.Context, userID int64) ([]APIKey, int, error) {
+	keys, code, err := s.apiKey.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	res := make([]APIKey, len(keys))
+	for i, key := range keys {
+		res[i] = s.apiKeyFromEntity(*key)
+	}
+
+	return res, http.StatusOK, nil
+}
+
+// CreateAPIKeyRequest is create api key request model.
+type CreateAPIKeyRequest struct {
+	UserID    int64      `json:"-" validate:"required" swaggerignore:"true"`
+	Name      string     `json:"name" validate:"required" mod:"trim"`
+	Scopes    []string   `json:"scopes" validate:"required,gt=0,dive,oneof=images:read images:write" mod:"dive,trim,lcase"`
+	ExpiredAt *time.Time `json:"expired_at"`
+}
+
+// CreateAPIKey to create new api key.
+// The key is only shown once.
+func (s *service) CreateAPIKey(ctx context.Context, data CreateAPIKeyRequest) (*APIKey, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	if data.ExpiredAt != nil && !data.ExpiredAt.After(time.Now()) {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrGTField("expired_at", "now"))
+	}
+
+	prefix, err := s.generateRandomString(apiKeyPrefixBytes, hex.EncodeToString)
+	if err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+	}
+
+	secret, err := s.generateRandomString(apiKeySecretBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+	}
+
+	key, code, err := s.apiKey.Create(ctx, entity.APIKey{
+		UserID:    data.UserID,
+		Name:      data.Name,
+		Prefix:    prefix,
+		Hash:      s.hashSecret(secret),
+		Scopes:    s.uniqueScopes(data.Scopes),
+		ExpiredAt: data.ExpiredAt,
+	})
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	res := s.apiKeyFromEntity(*key)
+	res.Key = apiKeyPrefix + prefix + "_" + secret
+
+	return &res, http.StatusCreated, nil
+}
+
+// DeleteAPIKeyRequest is delete api key request model.
+type DeleteAPIKeyRequest struct {
+	UserID int64 `validate:"required"`
+	ID     int64 `validate:"required"`
+}
+
+// DeleteAPIKey to revoke api key.
+func (s *service) DeleteAPIKey(ctx context.Context, data DeleteAPIKeyRequest) (int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.apiKey.Delete(ctx, entity.APIKey{
+		ID:     data.ID,
+		UserID: data.UserID,
+	}); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// ValidateAPIKey to validate api key and its scope.
+func (s *service) ValidateAPIKey(ctx context.Context, key, scope string) (*JWTClaim, int, error) {
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(key, apiKeyPrefix), "_")
+	if !ok || !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, http.StatusUnauthorized, stack.Wrap(ctx, errors.ErrInvalidAPIKey)
+	}
+
+	apiKey, code, err := s.apiKey.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if code == http.StatusNotFound {
+			return nil, http.StatusUnauthorized, stack.Wrap(ctx, err, errors.ErrInvalidAPIKey)
+		}
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(s.hashSecret(secret)), []byte(apiKey.Hash)) != 1 {
+		return nil, http.StatusUnauthorized, stack.Wrap(ctx, errors.ErrInvalidAPIKey)
+	}
+
+	if apiKey.ExpiredAt != nil && apiKey.ExpiredAt.Before(time.Now()) {
+		return nil, http.StatusUnauthorized, stack.Wrap(ctx, errors.ErrInvalidAPIKey)
+	}
+
+	if !s.hasScope(apiKey.Scopes, scope) {
+		return nil, http.StatusForbidden, stack.Wrap(ctx, errors.ErrInsufficientScope)
+	}
+
+	user, code, err := s.user.GetByID(ctx, apiKey.UserID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if user.Suspended {
+		return nil, http.StatusForbidden, stack.Wrap(ctx, errors.ErrSuspendedUser)
+	}
+
+	// Only update last used time occasionally.
+	// Should not block request if failed.
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > apiKeyLastUsedStep {
+		if _, err := s.apiKey.UpdateLastUsedAt(ctx, apiKey.ID); err != nil {
+			utils.Error(stack.Wrap(ctx, err).Error())
+		}
+	}
+
+	return &JWTClaim{UserID: apiKey.UserID}, http.StatusOK, nil
+}
+
+func (s *service) generateRandomString(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
+
+// hashSecret to hash random secret like api key
+// and recovery code. The secret has high entropy,
+// so a plain sha256 is enough.
+func (s *service) hashSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+func (s *service) hasScope(scopes []string, scope string) bool {
+	for _, sc := range scopes {
+		if sc == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) uniqueScopes(scopes []string) []string {
+	var res []string
+	for _, scope := range scopes {
+		if !s.hasScope(res, scope) {
+			res = append(res, scope)
+		}
+	}
+	return res
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/attempt/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// LoginLimitConfig is failed login attempt limit config.
+type LoginLimitConfig struct {
+	// Failures allowed before backoff delay applies.
+	FreeFailures int
+	// Failures before lockout, per username and per ip.
+	UsernameMaxFailures int
+	IPMaxFailures       int
+	// Backoff delay is doubled for each failure after
+	// free failures, up to max.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	Lockout     time.Duration
+	// Attempts are forgotten after no attempt in this window.
+	Window time.Duration
+}
+
+type attemptKey struct {
+	key         string
+	maxFailures int
+}
+
+func (s *service) getLoginAttemptKeys(username, ip string) []attemptKey {
+	keys := []attemptKey{{key: "login:username:" + username, maxFailures: s.cfg.LoginLimit.UsernameMaxFailures}}
+	if ip != "" {
+		keys = append(keys, attemptKey{key: "login:ip:" + ip, maxFailures: s.cfg.LoginLimit.IPMaxFailures})
+	}
+	return keys
+}
+
+// checkLoginAttempt to check if login is allowed now
+// and count the attempt. The attempt is counted before
+// the login is verified so concurrent attempts can't
+// get through more than the max failures.
+func (s *service) checkLoginAttempt(ctx context.Context, keys []attemptKey) (int, error) {
+	cfg := s.cfg.LoginLimit
+	now := time.Now()
+
+	var retryAfter time.Duration
+	for _, key := range keys {
+		attempt, code, err := s.attempt.Get(ctx, key.key)
+		if err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		for _, t := range []time.Time{attempt.LockedUntil, attempt.NextAttemptAt} {
+			if wait := t.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return http.StatusTooManyRequests, stack.Wrap(ctx, errors.NewRetryAfterError(errors.ErrTooManyLoginAttempts, retryAfter))
+	}
+
+	for _, key := range keys {
+		cnt, code, err := s.attempt.Incr(ctx, key.key, 1, cfg.Window)
+		if err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		// Concurrent attempts after the last allowed one.
+		if key.maxFailures > 0 && cnt > int64(key.maxFailures) {
+			if code, err := s.lockLoginAttempt(ctx, key.key); err != nil {
+				return code, stack.Wrap(ctx, err)
+			}
+			return http.StatusTooManyRequests, stack.Wrap(ctx, errors.NewRetryAfterError(errors.ErrTooManyLoginAttempts, cfg.Lockout))
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// addLoginFailure to apply backoff delay or lockout
+// from the attempt count after the failed login.
+func (s *service) addLoginFailure(ctx context.Context, keys []attemptKey) (int, error) {
+	cfg := s.cfg.LoginLimit
+	now := time.Now()
+
+	for _, key := range keys {
+		// Already counted when checked.
+		cnt, code, err := s.attempt.Incr(ctx, key.key, 0, cfg.Window)
+		if err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		switch {
+		case key.maxFailures > 0 && cnt >= int64(key.maxFailures):
+			if code, err := s.lockLoginAttempt(ctx, key.key); err != nil {
+				return code, stack.Wrap(ctx, err)
+			}
+		case cnt > int64(cfg.FreeFailures):
+			delay := time.Duration(float64(cfg.BackoffBase) * math.Pow(2, float64(cnt-int64(cfg.FreeFailures)-1)))
+			if delay > cfg.BackoffMax || delay <= 0 {
+				delay = cfg.BackoffMax
+			}
+			if code, err := s.attempt.Set(ctx, key.key, entity.Attempt{NextAttemptAt: now.Add(delay)}, delay); err != nil {
+				return code, stack.Wrap(ctx, err)
+			}
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// lockLoginAttempt to lock out the key and start
+// counting again after the lockout.
+func (s *service) lockLoginAttempt(ctx context.Context, key string) (int, error) {
+	if code, err := s.attempt.Delete(ctx, key); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	lockout := s.cfg.LoginLimit.Lockout
+	if code, err := s.attempt.Set(ctx, key, entity.Attempt{LockedUntil: time.Now().Add(lockout)}, lockout); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// failLogin to record failed login and return the
+// login error. Should not block the response if failed.
+func (s *service) failLogin(ctx context.Context, keys []attemptKey, loginErr error) error {
+	if _, err := s.addLoginFailure(ctx, keys); err != nil {
+		utils.Error(stack.Wrap(ctx, err).Error())
+	}
+	return loginErr
+}
+
+// passLogin to reset username failed attempts and
+// uncount the attempt of the other keys. Ip failed
+// attempts are kept so a valid login can't be used
+// to reset guessing on other usernames. Should not
+// block the response if failed.
+func (s *service) passLogin(ctx context.Context, keys []attemptKey) {
+	if _, err := s.attempt.Delete(ctx, keys[0].key); err != nil {
+		utils.Error(stack.Wrap(ctx, err).Error())
+	}
+
+	for _, key := range keys[1:] {
+		if _, _, err := s.attempt.Incr(ctx, key.key, -1, s.cfg.LoginLimit.Window); err != nil {
+			utils.Error(stack.Wrap(ctx, err).Error())
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	_errors "errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	attemptCache "github.com/rl404/image-randomizer/internal/domain/attempt/repository/cache"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/pkg/cache"
+)
+
+func newAttemptTest(t *testing.T, cfg LoginLimitConfig) *service {
+	t.Helper()
+
+	store, err := cache.NewStore(cache.InMemory, "", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return &service{
+		attempt: attemptCache.New(store),
+		cfg:     Config{LoginLimit: cfg},
+	}
+}
+
+func TestCheckLoginAttemptConcurrent(t *testing.T) {
+	s := newAttemptTest(t, LoginLimitConfig{
+		FreeFailures:        10,
+		UsernameMaxFailures: 3,
+		Lockout:             time.Minute,
+		Window:              time.Minute,
+	})
+	ctx := context.Background()
+	keys := s.getLoginAttemptKeys("user", "")
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.checkLoginAttempt(ctx, keys); err == nil {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := allowed.Load(); n != 3 {
+		t.Errorf("allowed = %d, want 3", n)
+	}
+
+	if _, err := s.checkLoginAttempt(ctx, keys); !_errors.Is(err, errors.ErrTooManyLoginAttempts) {
+		t.Errorf("err = %v, want %v", err, errors.ErrTooManyLoginAttempts)
+	}
+}
+
+func TestLoginAttempt(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		pass     bool
+		wantErr  error
+	}{
+		{
+			name:     "free failures",
+			failures: 2,
+		},
+		{
+			name:     "backoff",
+			failures: 3,
+			wantErr:  errors.ErrTooManyLoginAttempts,
+		},
+		{
+			name:     "lockout",
+			failures: 5,
+			wantErr:  errors.ErrTooManyLoginAttempts,
+		},
+		{
+			name:     "reset after valid login",
+			failures: 4,
+			pass:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newAttemptTest(t, LoginLimitConfig{
+				FreeFailures:        2,
+				UsernameMaxFailures: 5,
+				BackoffBase:         20 * time.Millisecond,
+				BackoffMax:          20 * time.Millisecond,
+				Lockout:             time.Minute,
+				Window:              time.Minute,
+			})
+			ctx := context.Background()
+			keys := s.getLoginAttemptKeys("user", "")
+
+			for i := range tt.failures {
+				// Wait for backoff of previous failure.
+				time.Sleep(30 * time.Millisecond)
+				if _, err := s.checkLoginAttempt(ctx, keys); err != nil {
+					t.Fatalf("attempt %d: err = %v", i+1, err)
+				}
+				s.failLogin(ctx, keys, errors.ErrInvalidLogin)
+			}
+
+			if tt.pass {
+				time.Sleep(30 * time.Millisecond)
+				if _, err := s.checkLoginAttempt(ctx, keys); err != nil {
+					t.Fatalf("valid attempt: err = %v", err)
+				}
+				s.passLogin(ctx, keys)
+			}
+
+			if _, err := s.checkLoginAttempt(ctx, keys); !_errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/domainpolicy/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// DomainPolicy is instance-wide image domain policy model.
+type DomainPolicy struct {
+	Mode  entity.Mode  `json:"mode"`
+	Rules []DomainRule `json:"rules"`
+}
+
+// DomainRule is domain policy rule model.
+type DomainRule struct {
+	ID        int64     `json:"id"`
+	Pattern   string    `json:"pattern"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminGetDomainPolicy to get image domain policy and its rules.
+func (s *service) AdminGetDomainPolicy(ctx context.Context) (*DomainPolicy, int, error) {
+	policy, code, err := s.domainPolicy.GetPolicy(ctx)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	rules, code, err := s.domainPolicy.GetRules(ctx)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	res := DomainPolicy{
+		Mode:  policy.Mode,
+		Rules: make([]DomainRule, len(rules)),
+	}
+
+	for i, r := range rules {
+		res.Rules[i] = DomainRule{
+			ID:        r.ID,
+			Pattern:   r.Pattern,
+			CreatedAt: r.CreatedAt,
+		}
+	}
+
+	return &res, http.StatusOK, nil
+}
+
+// AdminUpdateDomainModeRequest is update domain policy mode request model.
+type AdminUpdateDomainModeRequest struct {
+	// Blocklist to block image from hosts in the rules.
+	// Allowlist to only allow image from hosts in the rules.
+	Mode entity.Mode `json:"mode" validate:"required,oneof=blocklist allowlist" mod:"trim,lcase"`
+}
+
+// AdminUpdateDomainMode to update image domain policy mode.
+func (s *service) AdminUpdateDomainMode(ctx context.Context, data AdminUpdateDomainModeRequest) (int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.domainPolicy.UpdateMode(ctx, data.Mode); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// AdminCreateDomainRuleRequest is create domain rule request model.
+type AdminCreateDomainRuleRequest struct {
+	// Host like example.com or *.example.com for subdomains.
+	Pattern string `json:"pattern" validate:"required,host_pattern"`
+}
+
+// AdminCreateDomainRule to add image domain policy rule.
+func (s *service) AdminCreateDomainRule(ctx context.Context, data AdminCreateDomainRuleRequest) (*DomainRule, int, error) {
+	data.Pattern = strings.ToLower(strings.TrimSpace(data.Pattern))
+
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	rule, code, err := s.domainPolicy.CreateRule(ctx, data.Pattern)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return &DomainRule{
+		ID:        rule.ID,
+		Pattern:   rule.Pattern,
+		CreatedAt: rule.CreatedAt,
+	}, http.StatusCreated, nil
+}
+
+// AdminDeleteDomainRule to delete image domain policy rule.
+func (s *service) AdminDeleteDomainRule(ctx context.Context, id int64) (int, error) {
+	if code, err := s.domainPolicy.DeleteRule(ctx, id); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+	return http.StatusOK, nil
+}
+
+// checkImageDomain to check image url against domain policy.
+func (s *service) checkImageDomain(ctx context.Context, imageURL string) (int, error) {
+	policy, code, err := s.domainPolicy.GetPolicy(ctx)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if !policy.IsAllowed(imageURL) {
+		return http.StatusForbidden, stack.Wrap(ctx, errors.ErrBlockedDomain)
+	}
+
+	return http.StatusOK, nil
+}
+
+// downloadImage to download image after checking
+// the domain policy in case the rules are updated
+// after the image is saved.
+func (s *service) downloadImage(ctx context.Context, imageURL string) (io.ReadCloser, int, error) {
+	if code, err := s.checkImageDomain(ctx, imageURL); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	image, code, err := s.image.Download(ctx, imageURL)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return image, http.StatusOK, nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/hotlink/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// HotlinkSetting is hotlink protection setting model.
+type HotlinkSetting struct {
+	// Empty means hotlink protection is disabled.
+	Allowlist      []string                    `json:"allowlist"`
+	MissingReferer entity.MissingRefererPolicy `json:"missing_referer"`
+	BlockAction    entity.BlockAction          `json:"block_action"`
+	Placeholder    string                      `json:"placeholder"`
+}
+
+// GetHotlinkSetting to get hotlink protection setting.
+func (s *service) GetHotlinkSetting(ctx context.Context, userID int64) (*HotlinkSetting, int, error) {
+	setting, code, err := s.hotlink.GetSetting(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return &HotlinkSetting{
+		Allowlist:      setting.Allowlist,
+		MissingReferer: setting.MissingReferer,
+		BlockAction:    setting.BlockAction,
+		Placeholder:    setting.Placeholder,
+	}, http.StatusOK, nil
+}
+
+// UpdateHotlinkSettingRequest is update hotlink setting request model.
+type UpdateHotlinkSettingRequest struct {
+	UserID int64 `json:"-" validate:"required" swaggerignore:"true"`
+	// Host like example.com or *.example.com for subdomains.
+	Allowlist []string `json:"allowlist" validate:"lte=50,dive,host_pattern"`
+	// Allow, deny or fallback to placeholder
+	// for request without referer and origin.
+	MissingReferer entity.MissingRefererPolicy `json:"missing_referer" validate:"required,oneof=allow deny fallback" mod:"trim,lcase"`
+	// Response for request from host not in allowlist.
+	BlockAction entity.BlockAction `json:"block_action" validate:"required,oneof=forbidden placeholder" mod:"trim,lcase"`
+	// Required if using placeholder.
+	Placeholder string `json:"placeholder" validate:"omitempty,url" mod:"trim"`
+}
+
+// UpdateHotlinkSetting to update hotlink protection setting.
+func (s *service) UpdateHotlinkSetting(ctx context.Context, data UpdateHotlinkSettingRequest) (*HotlinkSetting, int, error) {
+	for i, host := range data.Allowlist {
+		data.Allowlist[i] = strings.ToLower(strings.TrimSpace(host))
+	}
+
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	if (data.BlockAction == entity.BlockActionPlaceholder || data.MissingReferer == entity.MissingRefererFallback) && data.Placeholder == "" {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrRequiredField("placeholder"))
+	}
+
+	allowlist := make([]string, 0, len(data.Allowlist))
+	for _, host := range data.Allowlist {
+		if !slices.Contains(allowlist, host) {
+			allowlist = append(allowlist, host)
+		}
+	}
+
+	if code, err := s.hotlink.SaveSetting(ctx, entity.Setting{
+		UserID:         data.UserID,
+		Allowlist:      allowlist,
+		MissingReferer: data.MissingReferer,
+		BlockAction:    data.BlockAction,
+		Placeholder:    data.Placeholder,
+	}); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return &HotlinkSetting{
+		Allowlist:      allowlist,
+		MissingReferer: data.MissingReferer,
+		BlockAction:    data.BlockAction,
+		Placeholder:    data.Placeholder,
+	}, http.StatusOK, nil
+}
+
+// HotlinkBlock is blocked hotlink counter model.
+type HotlinkBlock struct {
+	// Empty if the request has no referer and origin.
+	Host          string    `json:"host"`
+	Count         int64     `json:"count"`
+	LastBlockedAt time.Time `json:"last_blocked_at"`
+}
+
+// GetHotlinkBlocks to get most blocked hotlink hosts.
+func (s *service) GetHotlinkBlocks(ctx context.Context, userID int64) ([]HotlinkBlock, int, error) {
+	blocks, code, err := s.hotlink.GetBlocks(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	res := make([]HotlinkBlock, len(blocks))
+	for i, b := range blocks {
+		res[i] = HotlinkBlock{
+			Host:          b.Host,
+			Count:         b.Count,
+			LastBlockedAt: b.LastBlockedAt,
+		}
+	}
+
+	return res, http.StatusOK, nil
+}
+
+// checkHotlink to check request origin against user's
+// hotlink allowlist. Will return placeholder image url
+// if the request should get placeholder instead.
+func (s *service) checkHotlink(ctx context.Context, userID int64, origin, referer string) (string, int, error) {
+	setting, code, err := s.hotlink.GetSetting(ctx, userID)
+	if err != nil {
+		return "", code, stack.Wrap(ctx, err)
+	}
+
+	if len(setting.Allowlist) == 0 {
+		return "", http.StatusOK, nil
+	}
+
+	host := getRequestHost(origin, referer)
+
+	action := setting.BlockAction
+	if host == "" {
+		switch setting.MissingReferer {
+		case entity.MissingRefererDeny:
+			action = entity.BlockActionForbidden
+		case entity.MissingRefererFallback:
+			action = entity.BlockActionPlaceholder
+		default:
+			return "", http.StatusOK, nil
+		}
+	} else if utils.MatchHost(setting.Allowlist, host) {
+		return "", http.StatusOK, nil
+	}
+
+	if _, err := s.hotlink.AddBlock(ctx, userID, host); err != nil {
+		utils.Error(stack.Wrap(ctx, err).Error())
+	}
+
+	if action == entity.BlockActionPlaceholder && setting.Placeholder != "" {
+		return setting.Placeholder, http.StatusOK, nil
+	}
+
+	return "", http.StatusForbidden, stack.Wrap(ctx, errors.ErrHotlinkBlocked)
+}
+
+// getRequestHost to get host from origin or referer header.
+func getRequestHost(origin, referer string) string {
+	for _, h := range []string{origin, referer} {
+		// Sent by sandboxed iframe and privacy-sensitive context.
+		if h == "" || h == "null" {
+			continue
+		}
+
+		if host := utils.GetURLHost(h); host != "" {
+			return host
+		}
+	}
+	return ""
+}
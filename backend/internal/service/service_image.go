@@ -2,10 +2,15 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/rl404/fairy/errors/stack"
 	"github.com/rl404/image-randomizer/internal/domain/image/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
 	"github.com/rl404/image-randomizer/internal/utils"
 )
 
@@ -47,11 +52,35 @@ func (s *service) CreateImage(ctx context.Context, data CreateImageRequest) (*Im
 		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
 	}
 
-	img, code, err := s.image.Create(ctx, entity.Image{
-		UserID: data.UserID,
-		Image:  data.Image,
-	})
-	if err != nil {
+	if code, err := s.checkImageDomain(ctx, data.Image); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	var img *entity.Image
+	if code, err := s.transactor.Transaction(ctx, func(ctx context.Context) (int, error) {
+		// Lock so concurrent requests of every instance
+		// can't pass the quota check before the image
+		// is created.
+		if code, err := s.quota.Lock(ctx, data.UserID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		if code, err := s.checkImageQuota(ctx, data.UserID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		var code int
+		var err error
+		img, code, err = s.image.Create(ctx, entity.Image{
+			UserID: data.UserID,
+			Image:  data.Image,
+		})
+		if err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		return http.StatusCreated, nil
+	}); err != nil {
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
@@ -75,6 +104,10 @@ func (s *service) UpdateImage(ctx context.Context, data UpdateImageRequest) (int
 		return http.StatusBadRequest, stack.Wrap(ctx, err)
 	}
 
+	if code, err := s.checkImageDomain(ctx, data.Image); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
 	if code, err := s.image.Update(ctx, entity.Image{
 		ID:     data.ImageID,
 		UserID: data.UserID,
@@ -107,3 +140,89 @@ func (s *service) DeleteImage(ctx context.Context, data DeleteImageRequest) (int
 
 	return http.StatusOK, nil
 }
+
+// SignedURLConfig is signed url config.
+type SignedURLConfig struct {
+	Expired    time.Duration
+	MaxExpired time.Duration
+}
+
+// SignedURL is signed url model.
+type SignedURL struct {
+	URL       string    `json:"url"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// CreateImageURLRequest is create image signed url request model.
+type CreateImageURLRequest struct {
+	UserID  int64 `validate:"required"`
+	ImageID int64 `validate:"required"`
+	// In seconds. Set 0 to use default expiration.
+	ExpiredIn int `validate:"gte=0"`
+}
+
+// CreateImageURL to create short-lived signed url
+// to preview the image without session.
+func (s *service) CreateImageURL(ctx context.Context, data CreateImageURLRequest) (*SignedURL, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	img, code, err := s.image.GetByID(ctx, data.ImageID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if img.UserID != data.UserID {
+		return nil, http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundImage)
+	}
+
+	expired := s.cfg.SignedURL.Expired
+	if data.ExpiredIn > 0 {
+		expired = time.Duration(data.ExpiredIn) * time.Second
+	}
+
+	if expired > s.cfg.SignedURL.MaxExpired {
+		expired = s.cfg.SignedURL.MaxExpired
+	}
+
+	expiredAt := time.Now().Add(expired)
+
+	return &SignedURL{
+		URL:       s.signer.Sign(getImagePreviewPath(img.ID), ScopeImagesRead, expiredAt),
+		ExpiredAt: expiredAt,
+	}, http.StatusOK, nil
+}
+
+// GetImagePreviewRequest is get image preview request model.
+type GetImagePreviewRequest struct {
+	ImageID int64
+	Query   url.Values
+}
+
+// GetImagePreview to get image using signed url.
+func (s *service) GetImagePreview(ctx context.Context, data GetImagePreviewRequest) (io.ReadCloser, int, error) {
+	if err := s.signer.Verify(getImagePreviewPath(data.ImageID), ScopeImagesRead, data.Query); err != nil {
+		return nil, http.StatusForbidden, stack.Wrap(ctx, err, errors.ErrInvalidSignedURL)
+	}
+
+	img, code, err := s.image.GetByID(ctx, data.ImageID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.checkBandwidthQuota(ctx, img.UserID); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	image, code, err := s.downloadImage(ctx, img.Image)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return s.meterImage(ctx, img.UserID, image), http.StatusOK, nil
+}
+
+func getImagePreviewPath(imageID int64) string {
+	return fmt.Sprintf("/images/%d/preview", imageID)
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/invite/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// Invite is registration invite model.
+type Invite struct {
+	ID        int64      `json:"id"`
+	Code      string     `json:"code"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	ExpiredAt *time.Time `json:"expired_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (s *service) inviteFromEntity(data entity.Invite) Invite {
+	return Invite{
+		ID:        data.ID,
+		Code:      data.Code,
+		MaxUses:   data.MaxUses,
+		Uses:      data.Uses,
+		ExpiredAt: data.ExpiredAt,
+		CreatedAt: data.CreatedAt,
+	}
+}
+
+// AdminGetInvites to get all registration invites.
+func (s *service) AdminGetInvites(ctx context.Context) ([]Invite, int, error) {
+	invites, code, err := s.invite.GetAll(ctx)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	res := make([]Invite, len(invites))
+	for i, inv := range invites {
+		res[i] = s.inviteFromEntity(*inv)
+	}
+
+	return res, http.StatusOK, nil
+}
+
+// AdminCreateInviteRequest is create invite request model.
+type AdminCreateInviteRequest struct {
+	// Default 1.
+	MaxUses int `json:"max_uses" validate:"gte=0"`
+	// Empty for no expiration.
+	ExpiredAt *time.Time `json:"expired_at"`
+}
+
+// AdminCreateInvite to create new registration invite.
+// The code is generated.
+func (s *service) AdminCreateInvite(ctx context.Context, data AdminCreateInviteRequest) (*Invite, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	if data.ExpiredAt != nil && !data.ExpiredAt.After(time.Now()) {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrGTField("expired_at", "now"))
+	}
+
+	if data.MaxUses == 0 {
+		data.MaxUses = 1
+	}
+
+	invite, code, err := s.invite.Create(ctx, entity.Invite{
+		MaxUses:   data.MaxUses,
+		ExpiredAt: data.ExpiredAt,
+	})
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	res := s.inviteFromEntity(*invite)
+
+	return &res, http.StatusCreated, nil
+}
+
+// AdminDeleteInvite to delete registration invite.
+func (s *service) AdminDeleteInvite(ctx context.Context, id int64) (int, error) {
+	if code, err := s.invite.Delete(ctx, id); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+	return http.StatusOK, nil
+}
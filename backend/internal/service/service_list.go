@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/user/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+const listTokenBytes = 24
+
+// List is random image list setting model.
+type List struct {
+	Visibility entity.Visibility `json:"visibility"`
+	// Only shown for unlisted and private list.
+	Token string `json:"token,omitempty"`
+}
+
+// GetList to get random image list setting.
+func (s *service) GetList(ctx context.Context, userID int64) (*List, int, error) {
+	user, code, err := s.user.GetByID(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return s.toList(user), http.StatusOK, nil
+}
+
+// UpdateListRequest is update list request model.
+type UpdateListRequest struct {
+	UserID     int64             `json:"-" validate:"required" swaggerignore:"true"`
+	Visibility entity.Visibility `json:"visibility" validate:"required,oneof=public unlisted private" mod:"trim,lcase"`
+}
+
+// UpdateList to update random image list visibility.
+func (s *service) UpdateList(ctx context.Context, data UpdateListRequest) (*List, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	user, code, err := s.user.GetByID(ctx, data.UserID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	user.Visibility = data.Visibility
+
+	// Keep existing token so embeds still work when
+	// switching back from public.
+	if user.Visibility != entity.VisibilityPublic && user.ListToken == "" {
+		if user.ListToken, err = s.generateRandomString(listTokenBytes, base64.RawURLEncoding.EncodeToString); err != nil {
+			return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+		}
+	}
+
+	if code, err := s.user.UpdateVisibility(ctx, *user); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return s.toList(user), http.StatusOK, nil
+}
+
+// RotateListToken to generate new list token.
+// Old unlisted urls and private signed urls
+// will not work anymore.
+func (s *service) RotateListToken(ctx context.Context, userID int64) (*List, int, error) {
+	user, code, err := s.user.GetByID(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if user.ListToken, err = s.generateRandomString(listTokenBytes, base64.RawURLEncoding.EncodeToString); err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+	}
+
+	if code, err := s.user.UpdateVisibility(ctx, *user); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return s.toList(user), http.StatusOK, nil
+}
+
+// ListURL is random image list url model.
+type ListURL struct {
+	URL       string     `json:"url"`
+	ExpiredAt *time.Time `json:"expired_at"`
+}
+
+// GetListURLRequest is get list url request model.
+type GetListURLRequest struct {
+	UserID int64 `validate:"required"`
+	// In seconds. Only for private list.
+	// Set 0 to never expire.
+	ExpiredIn int `validate:"gte=0"`
+}
+
+// GetListURL to get random image list url
+// according to its visibility.
+func (s *service) GetListURL(ctx context.Context, data GetListURLRequest) (*ListURL, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	user, code, err := s.user.GetByID(ctx, data.UserID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	path := getListPath(user.Username)
+
+	switch user.Visibility {
+	case entity.VisibilityUnlisted:
+		return &ListURL{URL: path + "?" + url.Values{"token": {user.ListToken}}.Encode()}, http.StatusOK, nil
+	case entity.VisibilityPrivate:
+		var res ListURL
+		var expiredAt time.Time
+		if data.ExpiredIn > 0 {
+			expiredAt = time.Now().Add(time.Duration(data.ExpiredIn) * time.Second)
+			res.ExpiredAt = &expiredAt
+		}
+		res.URL = s.signer.Sign(path, getListScope(user.ListToken), expiredAt)
+		return &res, http.StatusOK, nil
+	default:
+		return &ListURL{URL: path}, http.StatusOK, nil
+	}
+}
+
+// canAccessList to check if the query has access to
+// the user's random image list.
+func (s *service) canAccessList(user *entity.User, query url.Values) bool {
+	switch user.Visibility {
+	case entity.VisibilityUnlisted:
+		token := query.Get("token")
+		return token != "" && user.ListToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(user.ListToken)) == 1
+	case entity.VisibilityPrivate:
+		return user.ListToken != "" && s.signer.Verify(getListPath(user.Username), getListScope(user.ListToken), query) == nil
+	default:
+		return true
+	}
+}
+
+func (s *service) toList(user *entity.User) *List {
+	list := List{Visibility: user.Visibility}
+	if list.Visibility == "" {
+		list.Visibility = entity.VisibilityPublic
+	}
+	if list.Visibility != entity.VisibilityPublic {
+		list.Token = user.ListToken
+	}
+	return &list
+}
+
+func getListPath(username string) string {
+	return fmt.Sprintf("/user/%s/image.jpg", username)
+}
+
+// getListScope to bind signed url to the list token
+// so rotating the token invalidates the url.
+func getListScope(token string) string {
+	return "list:" + token
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	oidcEntity "github.com/rl404/image-randomizer/internal/domain/oidc/entity"
+	userEntity "github.com/rl404/image-randomizer/internal/domain/user/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/oidc"
+)
+
+// OIDCConfig is oidc login config.
+type OIDCConfig struct {
+	// Create new user if the identity is not linked
+	// to any user yet. Not affected by registration
+	// mode since the provider is trusted.
+	AutoRegister bool
+	StateExpired time.Duration
+}
+
+// OIDCURL is oidc provider authorization url.
+type OIDCURL struct {
+	URL string `json:"url"`
+	// Should be set in browser cookie and sent back
+	// in callback to bind the flow to the browser.
+	State     string    `json:"-"`
+	ExpiredAt time.Time `json:"-"`
+}
+
+// GetOIDCURL to get oidc provider authorization url.
+// Set user id to link the identity to the user
+// instead of login.
+func (s *service) GetOIDCURL(ctx context.Context, userID int64) (*OIDCURL, int, error) {
+	if s.oidcProvider == nil {
+		return nil, http.StatusNotFound, stack.Wrap(ctx, errors.ErrOIDCDisabled)
+	}
+
+	var values [3]string
+	for i := range values {
+		v, err := oidc.GenerateRandom()
+		if err != nil {
+			return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+		}
+		values[i] = v
+	}
+
+	state, nonce, codeVerifier := values[0], values[1], values[2]
+
+	url, err := s.oidcProvider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+	}
+
+	expiredAt := time.Now().Add(s.cfg.OIDC.StateExpired)
+
+	if code, err := s.oidc.CreateState(ctx, oidcEntity.State{
+		State:        state,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		UserID:       userID,
+		ExpiredAt:    expiredAt,
+	}); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return &OIDCURL{
+		URL:       url,
+		State:     state,
+		ExpiredAt: expiredAt,
+	}, http.StatusOK, nil
+}
+
+// OIDCCallbackRequest is oidc callback request model.
+type OIDCCallbackRequest struct {
+	Code  string
+	State string `validate:"required"`
+	// State from browser cookie set when
+	// starting the flow.
+	StateCookie      string `validate:"required"`
+	Error            string
+	ErrorDescription string
+	IP               string
+	UserAgent        string
+}
+
+// OIDCCallback to handle oidc provider callback.
+// Will return access and refresh token if login, or
+// nil token if linking identity to existing user.
+func (s *service) OIDCCallback(ctx context.Context, data OIDCCallbackRequest) (*Token, int, error) {
+	if s.oidcProvider == nil {
+		return nil, http.StatusNotFound, stack.Wrap(ctx, errors.ErrOIDCDisabled)
+	}
+
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	// State must be from the same browser which
+	// started the flow to prevent login csrf.
+	if subtle.ConstantTimeCompare([]byte(data.State), []byte(data.StateCookie)) != 1 {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidOIDCState)
+	}
+
+	// Consume state first so it can't be reused.
+	state, code, err := s.oidc.ConsumeState(ctx, data.State)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if data.Error != "" {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrOIDCProvider(data.Error, data.ErrorDescription))
+	}
+
+	if data.Code == "" {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrRequiredField("code"))
+	}
+
+	idToken, err := s.oidcProvider.Exchange(ctx, data.Code, state.CodeVerifier)
+	if err != nil {
+		return nil, http.StatusUnauthorized, stack.Wrap(ctx, err, errors.ErrInvalidOIDCToken)
+	}
+
+	claims, err := s.oidcProvider.Verify(ctx, idToken, state.Nonce)
+	if err != nil {
+		return nil, http.StatusUnauthorized, stack.Wrap(ctx, err, errors.ErrInvalidOIDCToken)
+	}
+
+	identity, code, err := s.oidc.GetIdentity(ctx, claims.Issuer, claims.Subject)
+	if err != nil && code != http.StatusNotFound {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	// Link identity to existing user.
+	if state.UserID != 0 {
+		if identity != nil {
+			if identity.UserID == state.UserID {
+				return nil, http.StatusOK, nil
+			}
+			return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrAlreadyLinkedOIDC)
+		}
+
+		if _, code, err := s.oidc.CreateIdentity(ctx, oidcEntity.Identity{
+			UserID:  state.UserID,
+			Issuer:  claims.Issuer,
+			Subject: claims.Subject,
+			Email:   claims.Email,
+		}); err != nil {
+			return nil, code, stack.Wrap(ctx, err)
+		}
+
+		return nil, http.StatusOK, nil
+	}
+
+	// Register new user.
+	if identity == nil {
+		if !s.cfg.OIDC.AutoRegister {
+			return nil, http.StatusForbidden, stack.Wrap(ctx, errors.ErrNotLinkedOIDC)
+		}
+
+		user, code, err := s.createOIDCUser(ctx, claims)
+		if err != nil {
+			return nil, code, stack.Wrap(ctx, err)
+		}
+
+		identity, code, err = s.oidc.CreateIdentity(ctx, oidcEntity.Identity{
+			UserID:  user.ID,
+			Issuer:  claims.Issuer,
+			Subject: claims.Subject,
+			Email:   claims.Email,
+		})
+		if err != nil {
+			return nil, code, stack.Wrap(ctx, err)
+		}
+	}
+
+	// Deleted user is excluded.
+	user, code, err := s.user.GetByID(ctx, identity.UserID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	// Oidc replaces password, not two-factor code.
+	challenge, code, err := s.createChallengeToken(ctx, user.ID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if challenge != nil {
+		return challenge, http.StatusOK, nil
+	}
+
+	// Create access and refresh token.
+	token, code, err := s.createToken(ctx, user.ID, utils.GenerateUUID(), data.IP, data.UserAgent)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return token, http.StatusOK, nil
+}
+
+var oidcUsernameInvalidChar = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// oidcUsernameMaxBase leaves room for random suffix.
+const oidcUsernameMaxBase = 27
+
+// createOIDCUser to create new user from oidc claims.
+// The user has no password and can only login with oidc
+// until they set one using change password.
+func (s *service) createOIDCUser(ctx context.Context, claims *oidc.Claims) (*userEntity.User, int, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	base = strings.Trim(oidcUsernameInvalidChar.ReplaceAllString(strings.ToLower(base), "-"), "-_")
+	if len(base) > oidcUsernameMaxBase {
+		base = base[:oidcUsernameMaxBase]
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	// Find valid and unused username.
+	username := base
+	for i := 0; ; i++ {
+		if utils.IsValidUsername(username) && !s.isReservedUsername(username) {
+			user, code, err := s.user.GetByUsername(ctx, username)
+			if err != nil && code != http.StatusNotFound {
+				return nil, code, stack.Wrap(ctx, err)
+			}
+
+			if user == nil {
+				break
+			}
+		}
+
+		if i >= 5 {
+			return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrDuplicateUsername)
+		}
+
+		suffix, err := s.generateRandomString(2, hex.EncodeToString)
+		if err != nil {
+			return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+		}
+
+		username = base + "-" + suffix
+	}
+
+	return s.user.Create(ctx, userEntity.User{
+		Username: username,
+	})
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	oidcEntity "github.com/rl404/image-randomizer/internal/domain/oidc/entity"
+	oidcRepository "github.com/rl404/image-randomizer/internal/domain/oidc/repository"
+	tokenEntity "github.com/rl404/image-randomizer/internal/domain/token/entity"
+	tokenRepository "github.com/rl404/image-randomizer/internal/domain/token/repository"
+	totpEntity "github.com/rl404/image-randomizer/internal/domain/totp/entity"
+	totpRepository "github.com/rl404/image-randomizer/internal/domain/totp/repository"
+	userEntity "github.com/rl404/image-randomizer/internal/domain/user/entity"
+	userRepository "github.com/rl404/image-randomizer/internal/domain/user/repository"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/pkg/oidc"
+	"github.com/rl404/image-randomizer/pkg/oidc/oidctest"
+)
+
+// Only the methods used by oidc flow are implemented.
+// Calling the others will panic.
+
+type fakeOIDCRepo struct {
+	oidcRepository.Repository
+
+	mu         sync.Mutex
+	states     map[string]oidcEntity.State
+	identities []oidcEntity.Identity
+}
+
+func (f *fakeOIDCRepo) CreateState(_ context.Context, data oidcEntity.State) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[data.State] = data
+	return http.StatusCreated, nil
+}
+
+func (f *fakeOIDCRepo) ConsumeState(_ context.Context, state string) (*oidcEntity.State, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.states[state]
+	delete(f.states, state)
+	if !ok || s.ExpiredAt.Before(time.Now()) {
+		return nil, http.StatusBadRequest, errors.ErrInvalidOIDCState
+	}
+	return &s, http.StatusOK, nil
+}
+
+func (f *fakeOIDCRepo) GetIdentity(_ context.Context, issuer, subject string) (*oidcEntity.Identity, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, i := range f.identities {
+		if i.Issuer == issuer && i.Subject == subject {
+			return &i, http.StatusOK, nil
+		}
+	}
+	return nil, http.StatusNotFound, errors.ErrNotLinkedOIDC
+}
+
+func (f *fakeOIDCRepo) CreateIdentity(_ context.Context, data oidcEntity.Identity) (*oidcEntity.Identity, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.identities = append(f.identities, data)
+	return &data, http.StatusCreated, nil
+}
+
+type fakeUserRepo struct {
+	userRepository.Repository
+	users map[int64]userEntity.User
+}
+
+func (f *fakeUserRepo) GetByID(_ context.Context, id int64) (*userEntity.User, int, error) {
+	u, ok := f.users[id]
+	if !ok {
+		return nil, http.StatusNotFound, errors.ErrNotFoundUser
+	}
+	return &u, http.StatusOK, nil
+}
+
+type fakeTOTPRepo struct {
+	totpRepository.Repository
+	totps map[int64]totpEntity.TOTP
+}
+
+func (f *fakeTOTPRepo) Get(_ context.Context, userID int64) (*totpEntity.TOTP, int, error) {
+	t, ok := f.totps[userID]
+	if !ok {
+		return nil, http.StatusNotFound, errors.ErrNotFoundUser
+	}
+	return &t, http.StatusOK, nil
+}
+
+type fakeTokenRepo struct {
+	tokenRepository.Repository
+}
+
+func (f *fakeTokenRepo) CreateChallengeToken(_ context.Context, data tokenEntity.CreateChallengeTokenRequest) (*tokenEntity.Token, int, error) {
+	return &tokenEntity.Token{ChallengeToken: "challenge:" + data.ChallengeUUID}, http.StatusCreated, nil
+}
+
+type oidcTest struct {
+	mock *oidctest.Provider
+	repo *fakeOIDCRepo
+	s    *service
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	t.Helper()
+
+	mock, err := oidctest.NewProvider("client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mock.Close)
+
+	repo := &fakeOIDCRepo{states: make(map[string]oidcEntity.State)}
+
+	return &oidcTest{
+		mock: mock,
+		repo: repo,
+		s: &service{
+			user: &fakeUserRepo{users: map[int64]userEntity.User{
+				1: {ID: 1, Username: "user"},
+				2: {ID: 2, Username: "user-2fa"},
+			}},
+			token: &fakeTokenRepo{},
+			oidc:  repo,
+			totp: &fakeTOTPRepo{totps: map[int64]totpEntity.TOTP{
+				2: {UserID: 2, Enabled: true},
+			}},
+			oidcProvider: oidc.New(oidc.Config{
+				Issuer:      mock.Issuer(),
+				ClientID:    "client",
+				RedirectURL: "http://localhost/oidc/callback",
+				Scopes:      []string{"openid"},
+			}, nil),
+			cfg: Config{OIDC: OIDCConfig{StateExpired: time.Minute}},
+		},
+	}
+}
+
+// start to start the flow and login at the provider.
+func (o *oidcTest) start(t *testing.T, userID int64, subject string) (*OIDCURL, string) {
+	t.Helper()
+
+	url, _, err := o.s.GetOIDCURL(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := o.mock.Authorize(url.URL, map[string]interface{}{"sub": subject})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return url, code
+}
+
+func TestOIDCCallbackLink(t *testing.T) {
+	o := newOIDCTest(t)
+	ctx := context.Background()
+
+	url, code := o.start(t, 1, "subject")
+
+	req := OIDCCallbackRequest{
+		Code:        code,
+		State:       url.State,
+		StateCookie: url.State,
+	}
+
+	token, status, err := o.s.OIDCCallback(ctx, req)
+	if err != nil {
+		t.Fatalf("status = %d, err = %v", status, err)
+	}
+
+	if token != nil {
+		t.Errorf("token = %+v, want nil", token)
+	}
+
+	if identity, _, err := o.repo.GetIdentity(ctx, o.mock.Issuer(), "subject"); err != nil || identity.UserID != 1 {
+		t.Errorf("identity = %+v, err = %v", identity, err)
+	}
+
+	// Replay the same callback.
+	if _, status, err := o.s.OIDCCallback(ctx, req); !_errors.Is(err, errors.ErrInvalidOIDCState) || status != http.StatusBadRequest {
+		t.Errorf("status = %d, err = %v, want %d %v", status, err, http.StatusBadRequest, errors.ErrInvalidOIDCState)
+	}
+}
+
+func TestOIDCCallbackStateCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie func(state string) string
+	}{
+		{
+			name:   "missing cookie",
+			cookie: func(string) string { return "" },
+		},
+		{
+			name:   "cookie from other flow",
+			cookie: func(string) string { return "other-state" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOIDCTest(t)
+			ctx := context.Background()
+
+			url, code := o.start(t, 1, "subject")
+
+			if _, status, err := o.s.OIDCCallback(ctx, OIDCCallbackRequest{
+				Code:        code,
+				State:       url.State,
+				StateCookie: tt.cookie(url.State),
+			}); err == nil || status != http.StatusBadRequest {
+				t.Errorf("status = %d, err = %v, want %d", status, err, http.StatusBadRequest)
+			}
+
+			if len(o.repo.identities) != 0 {
+				t.Errorf("identities = %+v, want empty", o.repo.identities)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackTOTP(t *testing.T) {
+	o := newOIDCTest(t)
+	ctx := context.Background()
+
+	o.repo.identities = append(o.repo.identities, oidcEntity.Identity{
+		UserID:  2,
+		Issuer:  o.mock.Issuer(),
+		Subject: "subject-2fa",
+	})
+
+	url, code := o.start(t, 0, "subject-2fa")
+
+	token, status, err := o.s.OIDCCallback(ctx, OIDCCallbackRequest{
+		Code:        code,
+		State:       url.State,
+		StateCookie: url.State,
+	})
+	if err != nil {
+		t.Fatalf("status = %d, err = %v", status, err)
+	}
+
+	if token == nil || token.ChallengeToken == "" || token.AccessToken != "" || token.RefreshToken != "" {
+		t.Errorf("token = %+v, want challenge token only", token)
+	}
+}
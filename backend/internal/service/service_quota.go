@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/quota/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// QuotaConfig is default quota config.
+// Set 0 for unlimited. There is no list quota
+// since each user only has a single list.
+type QuotaConfig struct {
+	ImageLimit   int64
+	MonthlyBytes int64
+}
+
+// Usage is user's quota usage model.
+type Usage struct {
+	Images       UsageItem `json:"images"`
+	MonthlyBytes UsageItem `json:"monthly_bytes"`
+	// When monthly bytes usage is reset.
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// UsageItem is quota usage item model.
+type UsageItem struct {
+	Used int64 `json:"used"`
+	// 0 means unlimited.
+	Limit int64 `json:"limit"`
+}
+
+// GetUsage to get user's quota usage.
+func (s *service) GetUsage(ctx context.Context, userID int64) (*Usage, int, error) {
+	quota, code, err := s.getQuota(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	images, code, err := s.image.Get(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	now := time.Now()
+
+	bytes, code, err := s.quota.GetMonthlyBytes(ctx, userID, getUsageMonth(now))
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return &Usage{
+		Images: UsageItem{
+			Used:  int64(len(images)),
+			Limit: quota.ImageLimit,
+		},
+		MonthlyBytes: UsageItem{
+			Used:  bytes,
+			Limit: quota.MonthlyBytes,
+		},
+		ResetAt: getUsageResetAt(now),
+	}, http.StatusOK, nil
+}
+
+// AdminGetUserUsage to get any user's quota usage.
+func (s *service) AdminGetUserUsage(ctx context.Context, userID int64) (*Usage, int, error) {
+	if _, code, err := s.user.GetByID(ctx, userID); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	usage, code, err := s.GetUsage(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return usage, http.StatusOK, nil
+}
+
+// AdminUpdateQuotaRequest is admin update user quota request model.
+type AdminUpdateQuotaRequest struct {
+	UserID int64 `json:"-" validate:"required" swaggerignore:"true"`
+	// Null to use default quota. 0 for unlimited.
+	ImageLimit *int64 `json:"image_limit" validate:"omitempty,gte=0"`
+	// Null to use default quota. 0 for unlimited.
+	MonthlyBytes *int64 `json:"monthly_bytes" validate:"omitempty,gte=0"`
+}
+
+// AdminUpdateQuota to override user's default quota.
+func (s *service) AdminUpdateQuota(ctx context.Context, data AdminUpdateQuotaRequest) (*Usage, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	if _, code, err := s.user.GetByID(ctx, data.UserID); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.quota.SaveQuota(ctx, entity.Quota{
+		UserID:       data.UserID,
+		ImageLimit:   data.ImageLimit,
+		MonthlyBytes: data.MonthlyBytes,
+	}); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	usage, code, err := s.GetUsage(ctx, data.UserID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return usage, http.StatusOK, nil
+}
+
+// getQuota to get user's quota with
+// default quota as fallback.
+func (s *service) getQuota(ctx context.Context, userID int64) (*QuotaConfig, int, error) {
+	override, code, err := s.quota.GetQuota(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	quota := s.cfg.Quota
+
+	if override.ImageLimit != nil {
+		quota.ImageLimit = *override.ImageLimit
+	}
+
+	if override.MonthlyBytes != nil {
+		quota.MonthlyBytes = *override.MonthlyBytes
+	}
+
+	return &quota, http.StatusOK, nil
+}
+
+// checkImageQuota to check if user can add more image.
+func (s *service) checkImageQuota(ctx context.Context, userID int64) (int, error) {
+	quota, code, err := s.getQuota(ctx, userID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if quota.ImageLimit == 0 {
+		return http.StatusOK, nil
+	}
+
+	// Count from database instead of cache to
+	// include images created in the transaction.
+	cnt, code, err := s.image.Count(ctx, userID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if cnt >= quota.ImageLimit {
+		return http.StatusForbidden, stack.Wrap(ctx, errors.ErrImageQuotaExceeded)
+	}
+
+	return http.StatusOK, nil
+}
+
+// checkBandwidthQuota to check if user's monthly
+// proxied bytes hasn't reached the quota.
+func (s *service) checkBandwidthQuota(ctx context.Context, userID int64) (int, error) {
+	quota, code, err := s.getQuota(ctx, userID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if quota.MonthlyBytes == 0 {
+		return http.StatusOK, nil
+	}
+
+	now := time.Now()
+
+	bytes, code, err := s.quota.GetMonthlyBytes(ctx, userID, getUsageMonth(now))
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if bytes >= quota.MonthlyBytes {
+		return http.StatusTooManyRequests, stack.Wrap(ctx, errors.NewRetryAfterError(errors.ErrBandwidthQuotaExceeded, getUsageResetAt(now).Sub(now)))
+	}
+
+	return http.StatusOK, nil
+}
+
+// meteredImage is image reader which counts
+// the bytes streamed to the response.
+type meteredImage struct {
+	io.ReadCloser
+	bytes   int64
+	onClose func(bytes int64)
+}
+
+// Read to read image and count the bytes.
+func (m *meteredImage) Read(p []byte) (int, error) {
+	n, err := m.ReadCloser.Read(p)
+	m.bytes += int64(n)
+	return n, err
+}
+
+// Close to close image and save the counted bytes.
+func (m *meteredImage) Close() error {
+	err := m.ReadCloser.Close()
+	m.onClose(m.bytes)
+	return err
+}
+
+// meterImage to count user's proxied bytes
+// when the image is streamed.
+func (s *service) meterImage(ctx context.Context, userID int64, image io.ReadCloser) io.ReadCloser {
+	return &meteredImage{
+		ReadCloser: image,
+		onClose: func(bytes int64) {
+			if bytes == 0 {
+				return
+			}
+
+			// Response may be done and request context is canceled.
+			ctx := context.WithoutCancel(ctx)
+			if _, err := s.quota.AddMonthlyBytes(ctx, userID, getUsageMonth(time.Now()), bytes); err != nil {
+				utils.Error(stack.Wrap(ctx, err).Error())
+			}
+		},
+	}
+}
+
+func getUsageMonth(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+func getUsageResetAt(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
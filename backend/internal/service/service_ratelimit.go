@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// RateLimitConfig is random image rate limit config.
+// Limit requests are allowed in each fixed window of
+// period. Set limit 0 to disable.
+type RateLimitConfig struct {
+	IPLimit        int
+	IPPeriod       time.Duration
+	UsernameLimit  int
+	UsernamePeriod time.Duration
+}
+
+// RateLimit is rate limit status model.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	// Time until the window is reset.
+	Reset  time.Duration
+	Period time.Duration
+}
+
+// TakeRandomImageLimitRequest is take random image limit request model.
+type TakeRandomImageLimitRequest struct {
+	IP       string
+	Username string
+}
+
+// TakeRandomImageLimit to count request of ip and username
+// windows. Will return the most restrictive status.
+func (s *service) TakeRandomImageLimit(ctx context.Context, data TakeRandomImageLimitRequest) (*RateLimit, int, error) {
+	type limitKey struct {
+		key    string
+		limit  int
+		period time.Duration
+	}
+
+	cfg := s.cfg.RateLimit
+
+	var keys []limitKey
+	if cfg.IPLimit > 0 && data.IP != "" {
+		keys = append(keys, limitKey{key: "random:ip:" + data.IP, limit: cfg.IPLimit, period: cfg.IPPeriod})
+	}
+	if cfg.UsernameLimit > 0 {
+		keys = append(keys, limitKey{key: "random:username:" + strings.ToLower(data.Username), limit: cfg.UsernameLimit, period: cfg.UsernamePeriod})
+	}
+
+	var res *RateLimit
+	for _, key := range keys {
+		limit, retryAfter, err := s.takeToken(ctx, key.key, key.limit, key.period)
+		if err != nil {
+			// Rather serve than fail because of cache.
+			utils.Error(stack.Wrap(ctx, err).Error())
+			continue
+		}
+
+		if res == nil || limit.Remaining < res.Remaining {
+			res = limit
+		}
+
+		if retryAfter > 0 {
+			return res, http.StatusTooManyRequests, stack.Wrap(ctx, errors.NewRetryAfterError(errors.ErrTooManyRequests, retryAfter))
+		}
+	}
+
+	return res, http.StatusOK, nil
+}
+
+// takeToken to count the request in the current window.
+// Will return retry after duration if the window has
+// reached the limit. The count is incremented atomically
+// so every instance sharing the cache sees the same count.
+func (s *service) takeToken(ctx context.Context, key string, limit int, period time.Duration) (*RateLimit, time.Duration, error) {
+	now := time.Now()
+	window := now.Truncate(period)
+	reset := window.Add(period).Sub(now)
+
+	cnt, _, err := s.rateLimit.Incr(ctx, key+":"+strconv.FormatInt(window.Unix(), 10), reset)
+	if err != nil {
+		return nil, 0, stack.Wrap(ctx, err)
+	}
+
+	var retryAfter time.Duration
+	if cnt > int64(limit) {
+		retryAfter = reset
+	}
+
+	return &RateLimit{
+		Limit:     limit,
+		Remaining: max(limit-int(cnt), 0),
+		Reset:     reset,
+		Period:    period,
+	}, retryAfter, nil
+}
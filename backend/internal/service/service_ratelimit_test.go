@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ratelimitCache "github.com/rl404/image-randomizer/internal/domain/ratelimit/repository/cache"
+	"github.com/rl404/image-randomizer/pkg/cache"
+)
+
+func TestTakeRandomImageLimitConcurrent(t *testing.T) {
+	store, err := cache.NewStore(cache.InMemory, "", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	s := &service{
+		rateLimit: ratelimitCache.New(store),
+		cfg: Config{RateLimit: RateLimitConfig{
+			IPLimit:        5,
+			IPPeriod:       time.Hour,
+			UsernameLimit:  100,
+			UsernamePeriod: time.Hour,
+		}},
+	}
+	ctx := context.Background()
+	req := TakeRandomImageLimitRequest{IP: "127.0.0.1", Username: "user"}
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := s.TakeRandomImageLimit(ctx, req); err == nil {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := allowed.Load(); n != 5 {
+		t.Errorf("allowed = %d, want 5", n)
+	}
+
+	limit, _, err := s.TakeRandomImageLimit(ctx, req)
+	if err == nil {
+		t.Fatal("err = nil, want too many requests")
+	}
+
+	if limit.Remaining != 0 || limit.Reset <= 0 || limit.Reset > time.Hour {
+		t.Errorf("limit = %+v", limit)
+	}
+}
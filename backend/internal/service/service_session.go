@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+)
+
+// Session is login session model.
+type Session struct {
+	ID         string    `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+// GetSessions to get active login sessions.
+func (s *service) GetSessions(ctx context.Context, data JWTClaim) ([]Session, int, error) {
+	sessions, code, err := s.token.GetSessions(ctx, data.UserID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	res := make([]Session, len(sessions))
+	for i, session := range sessions {
+		res[i] = Session{
+			ID:         session.ID,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			Current:    session.ID == data.FamilyID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiredAt:  session.ExpiredAt,
+		}
+	}
+
+	return res, http.StatusOK, nil
+}
+
+// DeleteSessionRequest is delete session request model.
+type DeleteSessionRequest struct {
+	UserID    int64  `validate:"required"`
+	SessionID string `validate:"required"`
+}
+
+// DeleteSession to revoke login session.
+func (s *service) DeleteSession(ctx context.Context, data DeleteSessionRequest) (int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	session, code, err := s.token.GetSession(ctx, data.SessionID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if session.UserID != data.UserID {
+		return http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundSession)
+	}
+
+	return s.revokeFamily(ctx, session.ID)
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/domain/view/entity"
+	viewRepository "github.com/rl404/image-randomizer/internal/domain/view/repository"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/export"
+)
+
+// Stats date format.
+const statsDateFormat = "2006-01-02"
+
+// Stats range limit.
+const (
+	statsDefaultDays = 30
+	statsMaxDays     = 366
+)
+
+// Max top referers shown in stats.
+const statsRefererLimit = 10
+
+// Stats is random image view stats model.
+type Stats struct {
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Total    int64          `json:"total"`
+	Images   []ImageStats   `json:"images"`
+	Days     []DailyStats   `json:"days"`
+	Referers []RefererStats `json:"referers"`
+}
+
+// ImageStats is view count per image model.
+type ImageStats struct {
+	ImageID int64 `json:"image_id"`
+	// Empty if the image has been deleted.
+	Image string `json:"image"`
+	Count int64  `json:"count"`
+}
+
+// DailyStats is view count per day model.
+type DailyStats struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+// RefererStats is view count per referer host model.
+type RefererStats struct {
+	// Empty if the request has no referer and origin.
+	Host  string `json:"host"`
+	Count int64  `json:"count"`
+}
+
+// GetStatsRequest is get stats request model.
+type GetStatsRequest struct {
+	UserID int64 `validate:"required"`
+	// Format YYYY-MM-DD in UTC. Default to 30 days before to.
+	From string `mod:"trim"`
+	// Format YYYY-MM-DD in UTC. Default to today.
+	To string `mod:"trim"`
+}
+
+// GetStats to get random image view stats.
+func (s *service) GetStats(ctx context.Context, data GetStatsRequest) (*Stats, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	from, to, code, err := getStatsRange(ctx, data.From, data.To)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	req := entity.CountRequest{
+		UserID: data.UserID,
+		From:   from,
+		To:     to,
+		Limit:  statsRefererLimit,
+	}
+
+	imageCounts, code, err := s.view.GetImageCounts(ctx, req)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	dailyCounts, code, err := s.view.GetDailyCounts(ctx, req)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	refererCounts, code, err := s.view.GetRefererCounts(ctx, req)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	images, code, err := s.image.Get(ctx, data.UserID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	imageMap := make(map[int64]string, len(images))
+	for _, img := range images {
+		imageMap[img.ID] = img.Image
+	}
+
+	res := Stats{
+		From:     from.Format(statsDateFormat),
+		To:       to.Format(statsDateFormat),
+		Images:   make([]ImageStats, len(imageCounts)),
+		Referers: make([]RefererStats, len(refererCounts)),
+	}
+
+	for i, c := range imageCounts {
+		res.Images[i] = ImageStats{
+			ImageID: c.ImageID,
+			Image:   imageMap[c.ImageID],
+			Count:   c.Count,
+		}
+	}
+
+	// Include days without view.
+	dailyMap := make(map[string]int64, len(dailyCounts))
+	for _, c := range dailyCounts {
+		dailyMap[c.Date.UTC().Format(statsDateFormat)] = c.Count
+	}
+
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		date := d.Format(statsDateFormat)
+		res.Days = append(res.Days, DailyStats{
+			Date:  date,
+			Count: dailyMap[date],
+		})
+		res.Total += dailyMap[date]
+	}
+
+	for i, c := range refererCounts {
+		res.Referers[i] = RefererStats{
+			Host:  c.Host,
+			Count: c.Count,
+		}
+	}
+
+	return &res, http.StatusOK, nil
+}
+
+// Stats export types.
+const (
+	StatsExportRaw   = "raw"
+	StatsExportDaily = "daily"
+)
+
+// ExportStatsRequest is export stats request model.
+type ExportStatsRequest struct {
+	// 0 for all users. Only used by cli.
+	UserID int64 `validate:"gte=0"`
+	// Format YYYY-MM-DD in UTC. Default to 30 days before to.
+	From string `mod:"trim"`
+	// Format YYYY-MM-DD in UTC. Default to today.
+	To     string        `mod:"trim"`
+	Format export.Format `validate:"required,oneof=csv jsonl" mod:"trim,lcase,default=csv"`
+	// Raw views or daily view count per image.
+	Type string `validate:"required,oneof=raw daily" mod:"trim,lcase,default=raw"`
+}
+
+// StatsExport is stats export which rows are
+// streamed when written.
+type StatsExport struct {
+	Filename    string
+	ContentType string
+	view        viewRepository.Repository
+	format      export.Format
+	exportType  string
+	request     entity.ExportRequest
+}
+
+// ExportStats to export user's random image view stats.
+func (s *service) ExportStats(ctx context.Context, data ExportStatsRequest) (*StatsExport, int, error) {
+	if data.UserID == 0 {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrRequiredField("user_id"))
+	}
+
+	exp, code, err := NewStatsExport(ctx, s.view, data)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return exp, http.StatusOK, nil
+}
+
+// NewStatsExport to create stats export.
+// Used by cli for instance-wide export.
+func NewStatsExport(ctx context.Context, view viewRepository.Repository, data ExportStatsRequest) (*StatsExport, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	from, to, code, err := getStatsRange(ctx, data.From, data.To)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return &StatsExport{
+		Filename:    fmt.Sprintf("stats-%s-%s-%s.%s", data.Type, from.Format(statsDateFormat), to.Format(statsDateFormat), data.Format),
+		ContentType: export.ContentType(data.Format),
+		view:        view,
+		format:      data.Format,
+		exportType:  data.Type,
+		request: entity.ExportRequest{
+			UserID: data.UserID,
+			From:   from,
+			To:     to,
+		},
+	}, http.StatusOK, nil
+}
+
+// Write to stream the export rows to w.
+func (e *StatsExport) Write(ctx context.Context, w io.Writer) (int, error) {
+	var code int
+	var err error
+	var ew *export.Writer
+
+	switch e.exportType {
+	case StatsExportDaily:
+		if ew, err = export.New(w, e.format, []string{"date", "user_id", "image_id", "count"}); err != nil {
+			return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+		}
+
+		code, err = e.view.ExportDailyCounts(ctx, e.request, func(c entity.DailyImageCount) error {
+			return ew.Write(c.Date.UTC().Format(statsDateFormat), c.UserID, c.ImageID, c.Count)
+		})
+	default:
+		if ew, err = export.New(w, e.format, []string{"time", "user_id", "image_id", "referer_host", "ua_class"}); err != nil {
+			return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+		}
+
+		code, err = e.view.ExportViews(ctx, e.request, func(v entity.View) error {
+			return ew.Write(v.CreatedAt.UTC().Format(time.RFC3339), v.UserID, v.ImageID, v.RefererHost, v.UAClass)
+		})
+	}
+
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if err := ew.Flush(); err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+	}
+
+	return http.StatusOK, nil
+}
+
+func getStatsRange(ctx context.Context, fromStr, toStr string) (from, to time.Time, code int, err error) {
+	now := time.Now().UTC()
+	to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if toStr != "" {
+		if to, err = time.Parse(statsDateFormat, toStr); err != nil {
+			return from, to, http.StatusBadRequest, stack.Wrap(ctx, err, errors.ErrInvalidDateRange)
+		}
+	}
+
+	from = to.AddDate(0, 0, 1-statsDefaultDays)
+	if fromStr != "" {
+		if from, err = time.Parse(statsDateFormat, fromStr); err != nil {
+			return from, to, http.StatusBadRequest, stack.Wrap(ctx, err, errors.ErrInvalidDateRange)
+		}
+	}
+
+	if from.After(to) || to.Sub(from) >= statsMaxDays*24*time.Hour {
+		return from, to, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidDateRange)
+	}
+
+	return from, to, http.StatusOK, nil
+}
+
+// recordView to record random image hit.
+// The view is saved asynchronously.
+func (s *service) recordView(ctx context.Context, userID, imageID int64, origin, referer, userAgent string) {
+	if _, err := s.view.Create(ctx, []entity.View{{
+		UserID:      userID,
+		ImageID:     imageID,
+		RefererHost: getRequestHost(origin, referer),
+		UAClass:     getUAClass(userAgent),
+		CreatedAt:   time.Now(),
+	}}); err != nil {
+		utils.Error(stack.Wrap(ctx, err).Error())
+	}
+}
+
+// Substrings of bot and link preview user agents.
+var botUAs = []string{"bot", "crawler", "spider", "preview", "facebookexternalhit", "curl", "wget", "python", "go-http-client", "okhttp", "java/"}
+
+// Substrings of mobile browser user agents.
+var mobileUAs = []string{"mobi", "android", "iphone", "ipad"}
+
+// getUAClass to get coarse user agent class.
+func getUAClass(userAgent string) entity.UAClass {
+	ua := strings.ToLower(userAgent)
+
+	for _, b := range botUAs {
+		if strings.Contains(ua, b) {
+			return entity.UAClassBot
+		}
+	}
+
+	for _, m := range mobileUAs {
+		if strings.Contains(ua, m) {
+			return entity.UAClassMobile
+		}
+	}
+
+	if strings.HasPrefix(ua, "mozilla/") {
+		return entity.UAClassDesktop
+	}
+
+	return entity.UAClassOther
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/rl404/fairy/errors/stack"
 	tokenEntity "github.com/rl404/image-randomizer/internal/domain/token/entity"
@@ -10,40 +11,216 @@ import (
 	"github.com/rl404/image-randomizer/internal/utils"
 )
 
+// TokenConfig is token config.
+type TokenConfig struct {
+	// Rotated refresh token is still accepted within
+	// the grace period so concurrent refresh or retry
+	// after lost response is not seen as token theft.
+	// Set 0 to disable.
+	RefreshGrace time.Duration
+}
+
 // Token is access and refresh token.
 type Token struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// Two-factor challenge token to be exchanged
+	// with access and refresh token.
+	ChallengeToken string `json:"challenge_token,omitempty"`
 }
 
 // JWTClaim is jwt claim.
 type JWTClaim struct {
-	UserID      int64  `json:"user_id"`
-	AccessUUID  string `json:"-"`
-	RefreshUUID string `json:"-"`
+	UserID        int64  `json:"user_id"`
+	AccessUUID    string `json:"-"`
+	RefreshUUID   string `json:"-"`
+	FamilyID      string `json:"-"`
+	ChallengeUUID string `json:"-"`
 }
 
-// RefreshToken to refresh token.
-func (s *service) RefreshToken(ctx context.Context, data JWTClaim) (*Token, int, error) {
+func (s *service) createToken(ctx context.Context, userID int64, familyID, ip, userAgent string) (*Token, int, error) {
+	user, code, err := s.user.GetByID(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if user.Suspended {
+		return nil, http.StatusForbidden, stack.Wrap(ctx, errors.ErrSuspendedUser)
+	}
+
+	refreshUUID := utils.GenerateUUID()
+
+	// Create refresh token.
+	refreshToken, code, err := s.token.CreateRefreshToken(ctx, tokenEntity.CreateRefreshTokenRequest{
+		UserID:      userID,
+		RefreshUUID: refreshUUID,
+		FamilyID:    familyID,
+		IP:          ip,
+		UserAgent:   userAgent,
+	})
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
 	// Create access token.
 	accessToken, code, err := s.token.CreateAccessToken(ctx, tokenEntity.CreateAccessTokenRequest{
-		UserID:     data.UserID,
-		AccessUUID: utils.GenerateUUID(),
+		UserID:      userID,
+		AccessUUID:  utils.GenerateUUID(),
+		RefreshUUID: refreshUUID,
+		FamilyID:    familyID,
 	})
 	if err != nil {
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
 	return &Token{
-		AccessToken: accessToken.AccessToken,
+		AccessToken:  accessToken.AccessToken,
+		RefreshToken: refreshToken.RefreshToken,
 	}, http.StatusOK, nil
 }
 
+// RefreshTokenRequest is refresh token request model.
+type RefreshTokenRequest struct {
+	UserID      int64
+	RefreshUUID string
+	FamilyID    string
+	IP          string
+	UserAgent   string
+}
+
+// RefreshToken to rotate refresh token and
+// create new access token.
+func (s *service) RefreshToken(ctx context.Context, data RefreshTokenRequest) (*Token, int, error) {
+	// Invalidate old refresh token. Failing means
+	// it has been used by another request.
+	if code, err := s.token.Rotate(ctx, data.RefreshUUID); err != nil {
+		if code != http.StatusUnauthorized {
+			return nil, code, stack.Wrap(ctx, err)
+		}
+
+		if token, _, err := s.token.GetDetail(ctx, data.RefreshUUID); err != nil || !s.isRefreshGrace(token) {
+			return nil, code, stack.Wrap(ctx, errors.ErrInvalidToken, s.revokeReusedToken(ctx, data.UserID, data.FamilyID))
+		}
+	}
+
+	// Create new token in the same family.
+	token, code, err := s.createToken(ctx, data.UserID, data.FamilyID, data.IP, data.UserAgent)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return token, http.StatusOK, nil
+}
+
 // ValidateToken to validate jwt token.
+// Suspended user's token is rejected even if
+// it's not revoked yet.
 func (s *service) ValidateToken(ctx context.Context, uuid string, userID int64) (int, error) {
-	value := s.token.Get(ctx, uuid)
-	if value != userID {
-		return http.StatusUnauthorized, stack.Wrap(ctx, errors.ErrInvalidToken)
+	if value := s.token.Get(ctx, uuid); value != userID {
+		// Check if it is an already-rotated refresh token.
+		token, _, err := s.token.GetDetail(ctx, uuid)
+		if err != nil || !token.Rotated || token.UserID != userID {
+			return http.StatusUnauthorized, stack.Wrap(ctx, errors.ErrInvalidToken)
+		}
+
+		if !s.isRefreshGrace(token) {
+			return http.StatusUnauthorized, stack.Wrap(ctx, errors.ErrInvalidToken, s.revokeReusedToken(ctx, token.UserID, token.FamilyID))
+		}
+	}
+
+	user, code, err := s.user.GetByID(ctx, userID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if user.Suspended {
+		return http.StatusForbidden, stack.Wrap(ctx, errors.ErrSuspendedUser)
+	}
+
+	return http.StatusOK, nil
+}
+
+// isRefreshGrace to check if the rotated refresh
+// token is still within the grace period.
+func (s *service) isRefreshGrace(token *tokenEntity.TokenDetail) bool {
+	return token.RotatedAt != nil && time.Since(*token.RotatedAt) <= s.cfg.Token.RefreshGrace
+}
+
+// revokeReusedToken to revoke the whole token family
+// when a rotated refresh token is used again.
+func (s *service) revokeReusedToken(ctx context.Context, userID int64, familyID string) error {
+	utils.Log(map[string]interface{}{
+		"level":     utils.WarnLevel,
+		"message":   "refresh token reuse detected, probable token theft",
+		"user_id":   userID,
+		"family_id": familyID,
+	})
+
+	// Legacy token has no family. Revoking empty
+	// family would revoke all legacy tokens.
+	if familyID == "" {
+		return errors.ErrInvalidToken
+	}
+
+	if _, err := s.revokeFamily(ctx, familyID); err != nil {
+		return stack.Wrap(ctx, err)
+	}
+
+	return errors.ErrInvalidToken
+}
+
+// Logout to revoke the token family of
+// the access token.
+func (s *service) Logout(ctx context.Context, data JWTClaim) (int, error) {
+	if data.FamilyID == "" {
+		return s.revokeToken(ctx, []string{data.AccessUUID})
+	}
+	return s.revokeFamily(ctx, data.FamilyID)
+}
+
+// LogoutAll to revoke all tokens of the user.
+func (s *service) LogoutAll(ctx context.Context, userID int64) (int, error) {
+	sessions, code, err := s.token.GetSessions(ctx, userID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	for _, session := range sessions {
+		if code, err := s.token.DeleteSession(ctx, session.ID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+	}
+
+	uuids, code, err := s.token.GetByUserID(ctx, userID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return s.revokeToken(ctx, uuids)
+}
+
+func (s *service) revokeFamily(ctx context.Context, familyID string) (int, error) {
+	uuids, code, err := s.token.GetByFamilyID(ctx, familyID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.revokeToken(ctx, uuids); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.token.DeleteSession(ctx, familyID); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *service) revokeToken(ctx context.Context, uuids []string) (int, error) {
+	for _, uuid := range uuids {
+		if code, err := s.token.Delete(ctx, uuid); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
 	}
 	return http.StatusOK, nil
 }
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	tokenEntity "github.com/rl404/image-randomizer/internal/domain/token/entity"
+	tokenRepository "github.com/rl404/image-randomizer/internal/domain/token/repository"
+	"github.com/rl404/image-randomizer/internal/errors"
+)
+
+// Only the methods used by token reuse flow are
+// implemented. Calling the others will panic.
+type fakeReuseTokenRepo struct {
+	tokenRepository.Repository
+
+	detail  tokenEntity.TokenDetail
+	revoked []string
+}
+
+func (f *fakeReuseTokenRepo) Rotate(context.Context, string) (int, error) {
+	return http.StatusUnauthorized, errors.ErrInvalidToken
+}
+
+func (f *fakeReuseTokenRepo) GetDetail(context.Context, string) (*tokenEntity.TokenDetail, int, error) {
+	return &f.detail, http.StatusOK, nil
+}
+
+func (f *fakeReuseTokenRepo) GetByFamilyID(_ context.Context, familyID string) ([]string, int, error) {
+	f.revoked = append(f.revoked, familyID)
+	return []string{f.detail.UUID}, http.StatusOK, nil
+}
+
+func (f *fakeReuseTokenRepo) Delete(context.Context, string) (int, error) {
+	return http.StatusOK, nil
+}
+
+func (f *fakeReuseTokenRepo) DeleteSession(context.Context, string) (int, error) {
+	return http.StatusOK, nil
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	rotatedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		familyID    string
+		wantRevoked []string
+	}{
+		{
+			name:        "revoke family",
+			familyID:    "family",
+			wantRevoked: []string{"family"},
+		},
+		{
+			name:     "legacy token without family",
+			familyID: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeReuseTokenRepo{detail: tokenEntity.TokenDetail{
+				UUID:      "refresh",
+				UserID:    1,
+				FamilyID:  tt.familyID,
+				Rotated:   true,
+				RotatedAt: &rotatedAt,
+			}}
+
+			s := &service{
+				token: repo,
+				cfg:   Config{Token: TokenConfig{RefreshGrace: time.Minute}},
+			}
+
+			_, code, err := s.RefreshToken(context.Background(), RefreshTokenRequest{
+				UserID:      1,
+				RefreshUUID: "refresh",
+				FamilyID:    tt.familyID,
+			})
+			if !_errors.Is(err, errors.ErrInvalidToken) || code != http.StatusUnauthorized {
+				t.Errorf("code = %d, err = %v, want %d %v", code, err, http.StatusUnauthorized, errors.ErrInvalidToken)
+			}
+
+			if !slices.Equal(repo.revoked, tt.wantRevoked) {
+				t.Errorf("revoked families = %q, want %q", repo.revoked, tt.wantRevoked)
+			}
+		})
+	}
+}
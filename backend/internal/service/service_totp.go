@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"encoding/base32"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rl404/fairy/errors/stack"
+	tokenEntity "github.com/rl404/image-randomizer/internal/domain/token/entity"
+	"github.com/rl404/image-randomizer/internal/domain/totp/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/internal/utils"
+	"github.com/rl404/image-randomizer/pkg/totp"
+)
+
+// TOTPConfig is totp config.
+type TOTPConfig struct {
+	// Issuer shown in authenticator apps.
+	Issuer string
+}
+
+const (
+	totpSkew           = 1
+	recoveryCodeCount  = 10
+	recoveryCodeBytes  = 10
+	recoveryCodeLength = 4
+)
+
+// TOTPEnrollment is totp enrollment model.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// RecoveryCodes is two-factor recovery codes model.
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnrollTOTP to generate new totp secret. Two-factor
+// authentication is enabled after confirmed.
+func (s *service) EnrollTOTP(ctx context.Context, userID int64) (*TOTPEnrollment, int, error) {
+	user, code, err := s.user.GetByID(ctx, userID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	t, code, err := s.totp.Get(ctx, userID)
+	if err != nil && code != http.StatusNotFound {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if t != nil && t.Enabled {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrAlreadyEnabledTOTP)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+	}
+
+	if code, err := s.totp.Save(ctx, entity.TOTP{
+		UserID: userID,
+		Secret: secret,
+	}); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.cfg.TOTP.Issuer, user.Username, secret),
+	}, http.StatusOK, nil
+}
+
+// ConfirmTOTPRequest is confirm totp request model.
+type ConfirmTOTPRequest struct {
+	UserID int64  `json:"-" validate:"required" swaggerignore:"true"`
+	Code   string `json:"code" validate:"required" mod:"trim"`
+}
+
+// ConfirmTOTP to enable two-factor authentication
+// and generate recovery codes.
+func (s *service) ConfirmTOTP(ctx context.Context, data ConfirmTOTPRequest) (*RecoveryCodes, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	t, code, err := s.totp.Get(ctx, data.UserID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if t.Enabled {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrAlreadyEnabledTOTP)
+	}
+
+	step, ok := totp.Validate(t.Secret, data.Code, time.Now(), totpSkew)
+	if !ok {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidTOTPCode)
+	}
+
+	if code, err := s.totp.Save(ctx, entity.TOTP{
+		UserID:       t.UserID,
+		Secret:       t.Secret,
+		Enabled:      true,
+		LastUsedStep: step,
+	}); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	codes, code, err := s.generateRecoveryCodes(ctx, data.UserID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return &RecoveryCodes{RecoveryCodes: codes}, http.StatusOK, nil
+}
+
+// DisableTOTPRequest is disable totp request model.
+type DisableTOTPRequest struct {
+	UserID int64 `json:"-" validate:"required" swaggerignore:"true"`
+	// Empty if user has no password (registered with oidc).
+	Password string `json:"password" mod:"trim"`
+}
+
+// DisableTOTP to disable two-factor authentication.
+func (s *service) DisableTOTP(ctx context.Context, data DisableTOTPRequest) (int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	user, code, err := s.user.GetByID(ctx, data.UserID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.verifyCurrentPassword(ctx, user, data.Password); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.totp.Delete(ctx, user.ID); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Login2FARequest is two-factor login request model.
+type Login2FARequest struct {
+	UserID        int64  `json:"-" validate:"required" swaggerignore:"true"`
+	ChallengeUUID string `json:"-" validate:"required" swaggerignore:"true"`
+	// Totp code or recovery code.
+	Code      string `json:"code" validate:"required" mod:"trim"`
+	IP        string `json:"-" swaggerignore:"true"`
+	UserAgent string `json:"-" swaggerignore:"true"`
+}
+
+// Login2FA to exchange challenge token and two-factor
+// code with access and refresh token.
+func (s *service) Login2FA(ctx context.Context, data Login2FARequest) (*Token, int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	// Check failed attempts.
+	attemptKeys := s.getLoginAttemptKeys("2fa:"+strconv.FormatInt(data.UserID, 10), data.IP)
+	if code, err := s.checkLoginAttempt(ctx, attemptKeys); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	t, code, err := s.totp.Get(ctx, data.UserID)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if !t.Enabled {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrNotFoundTOTP)
+	}
+
+	if code, err := s.verifyTOTPCode(ctx, *t, data.Code); err != nil {
+		if code == http.StatusBadRequest {
+			return nil, code, stack.Wrap(ctx, err, s.failLogin(ctx, attemptKeys, errors.ErrInvalidTOTPCode))
+		}
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	s.passLogin(ctx, attemptKeys)
+
+	// Challenge token can only be used once.
+	if code, err := s.token.Delete(ctx, data.ChallengeUUID); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	// Create access and refresh token.
+	token, code, err := s.createToken(ctx, data.UserID, utils.GenerateUUID(), data.IP, data.UserAgent)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return token, http.StatusOK, nil
+}
+
+// createChallengeToken to create two-factor challenge
+// token if the user has enabled it.
+func (s *service) createChallengeToken(ctx context.Context, userID int64) (*Token, int, error) {
+	t, code, err := s.totp.Get(ctx, userID)
+	if err != nil {
+		if code == http.StatusNotFound {
+			return nil, http.StatusOK, nil
+		}
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if !t.Enabled {
+		return nil, http.StatusOK, nil
+	}
+
+	token, code, err := s.token.CreateChallengeToken(ctx, tokenEntity.CreateChallengeTokenRequest{
+		UserID:        userID,
+		ChallengeUUID: utils.GenerateUUID(),
+	})
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return &Token{ChallengeToken: token.ChallengeToken}, http.StatusOK, nil
+}
+
+// verifyTOTPCode to verify totp code or recovery code.
+// Both can only be used once.
+func (s *service) verifyTOTPCode(ctx context.Context, t entity.TOTP, code string) (int, error) {
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(t.Secret, code, time.Now(), totpSkew)
+		if !ok {
+			return http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidTOTPCode)
+		}
+		return s.totp.Use(ctx, t.UserID, step)
+	}
+
+	return s.totp.UseRecoveryCode(ctx, t.UserID, s.hashSecret(s.normalizeRecoveryCode(code)))
+}
+
+func (s *service) generateRecoveryCodes(ctx context.Context, userID int64) ([]string, int, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := s.generateRandomString(recoveryCodeBytes, base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString)
+		if err != nil {
+			return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+		}
+
+		code = strings.ToLower(code)
+		hashes[i] = s.hashSecret(code)
+
+		// Group for readability.
+		var parts []string
+		for j := 0; j < len(code); j += recoveryCodeLength {
+			parts = append(parts, code[j:min(j+recoveryCodeLength, len(code))])
+		}
+		codes[i] = strings.Join(parts, "-")
+	}
+
+	if code, err := s.totp.CreateRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	return codes, http.StatusOK, nil
+}
+
+func (s *service) normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	_errors "errors"
+	"net/http"
+	"testing"
+	"time"
+
+	tokenEntity "github.com/rl404/image-randomizer/internal/domain/token/entity"
+	tokenRepository "github.com/rl404/image-randomizer/internal/domain/token/repository"
+	totpEntity "github.com/rl404/image-randomizer/internal/domain/totp/entity"
+	userEntity "github.com/rl404/image-randomizer/internal/domain/user/entity"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/pkg/totp"
+)
+
+// RFC 6238 test secret "12345678901234567890".
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Only the methods used by 2fa login flow are
+// implemented. Calling the others will panic.
+
+type fakeChallengeTokenRepo struct {
+	tokenRepository.Repository
+	tokens map[string]int64
+}
+
+func (f *fakeChallengeTokenRepo) Get(_ context.Context, token string) int64 {
+	return f.tokens[token]
+}
+
+func (f *fakeChallengeTokenRepo) GetDetail(context.Context, string) (*tokenEntity.TokenDetail, int, error) {
+	return nil, http.StatusNotFound, errors.ErrInvalidToken
+}
+
+func (f *fakeChallengeTokenRepo) Delete(_ context.Context, token string) (int, error) {
+	delete(f.tokens, token)
+	return http.StatusOK, nil
+}
+
+func (f *fakeChallengeTokenRepo) CreateRefreshToken(_ context.Context, data tokenEntity.CreateRefreshTokenRequest) (*tokenEntity.Token, int, error) {
+	f.tokens[data.RefreshUUID] = data.UserID
+	return &tokenEntity.Token{RefreshToken: "refresh:" + data.RefreshUUID}, http.StatusCreated, nil
+}
+
+func (f *fakeChallengeTokenRepo) CreateAccessToken(_ context.Context, data tokenEntity.CreateAccessTokenRequest) (*tokenEntity.Token, int, error) {
+	f.tokens[data.AccessUUID] = data.UserID
+	return &tokenEntity.Token{AccessToken: "access:" + data.AccessUUID}, http.StatusCreated, nil
+}
+
+type fakeUseTOTPRepo struct {
+	fakeTOTPRepo
+}
+
+func (f *fakeUseTOTPRepo) Use(_ context.Context, userID int64, step int64) (int, error) {
+	t := f.totps[userID]
+	if t.LastUsedStep >= step {
+		return http.StatusBadRequest, errors.ErrInvalidTOTPCode
+	}
+	t.LastUsedStep = step
+	f.totps[userID] = t
+	return http.StatusOK, nil
+}
+
+func newLogin2FATest(t *testing.T) (*service, *fakeChallengeTokenRepo) {
+	t.Helper()
+
+	s := newAttemptTest(t, LoginLimitConfig{
+		FreeFailures:        10,
+		UsernameMaxFailures: 10,
+		Lockout:             time.Minute,
+		Window:              time.Minute,
+	})
+
+	tokens := &fakeChallengeTokenRepo{tokens: make(map[string]int64)}
+
+	s.user = &fakeUserRepo{users: map[int64]userEntity.User{1: {ID: 1, Username: "user"}}}
+	s.token = tokens
+	s.totp = &fakeUseTOTPRepo{fakeTOTPRepo{totps: map[int64]totpEntity.TOTP{
+		1: {UserID: 1, Secret: testTOTPSecret, Enabled: true},
+	}}}
+
+	return s, tokens
+}
+
+func TestLogin2FAChallenge(t *testing.T) {
+	tests := []struct {
+		name string
+		// Challenge token still stored (not expired).
+		stored bool
+		// Use the challenge token before the test.
+		reused   bool
+		wantCode int
+		wantErr  error
+	}{
+		{
+			name:     "valid challenge",
+			stored:   true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "expired challenge",
+			wantCode: http.StatusUnauthorized,
+			wantErr:  errors.ErrInvalidToken,
+		},
+		{
+			name:     "reused challenge",
+			stored:   true,
+			reused:   true,
+			wantCode: http.StatusUnauthorized,
+			wantErr:  errors.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, tokens := newLogin2FATest(t)
+			ctx := context.Background()
+
+			if tt.stored {
+				tokens.tokens["challenge"] = 1
+			}
+
+			// Same steps as the challenge token middleware
+			// and the 2fa login handler.
+			login := func(d time.Duration) (int, error) {
+				if code, err := s.ValidateToken(ctx, "challenge", 1); err != nil {
+					return code, err
+				}
+
+				code, err := totp.Generate(testTOTPSecret, time.Now().Add(d))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				_, status, err := s.Login2FA(ctx, Login2FARequest{
+					UserID:        1,
+					ChallengeUUID: "challenge",
+					Code:          code,
+				})
+				return status, err
+			}
+
+			if tt.reused {
+				if code, err := login(-totp.Period * time.Second); err != nil {
+					t.Fatalf("first login: code = %d, err = %v", code, err)
+				}
+			}
+
+			code, err := login(0)
+			if code != tt.wantCode || !_errors.Is(err, tt.wantErr) {
+				t.Errorf("code = %d, err = %v, want %d %v", code, err, tt.wantCode, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLogin2FACodeSkew(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   time.Duration
+		wantCode int
+		wantErr  error
+	}{
+		{
+			name:     "current step",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "previous step",
+			offset:   -totp.Period * time.Second,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "next step",
+			offset:   totp.Period * time.Second,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "outside skew",
+			offset:   -2 * totp.Period * time.Second,
+			wantCode: http.StatusBadRequest,
+			wantErr:  errors.ErrInvalidTOTPCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, tokens := newLogin2FATest(t)
+			tokens.tokens["challenge"] = 1
+
+			code, err := totp.Generate(testTOTPSecret, time.Now().Add(tt.offset))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := Login2FARequest{
+				UserID:        1,
+				ChallengeUUID: "challenge",
+				Code:          code,
+			}
+
+			_, status, err := s.Login2FA(context.Background(), req)
+			if status != tt.wantCode || !_errors.Is(err, tt.wantErr) {
+				t.Fatalf("code = %d, err = %v, want %d %v", status, err, tt.wantCode, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			// Same code can only be used once.
+			tokens.tokens["challenge"] = 1
+			if _, status, err := s.Login2FA(context.Background(), req); status != http.StatusBadRequest || !_errors.Is(err, errors.ErrInvalidTOTPCode) {
+				t.Errorf("replay: code = %d, err = %v, want %d %v", status, err, http.StatusBadRequest, errors.ErrInvalidTOTPCode)
+			}
+		})
+	}
+}
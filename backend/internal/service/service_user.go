@@ -5,27 +5,123 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/rl404/fairy/errors/stack"
-	tokenEntity "github.com/rl404/image-randomizer/internal/domain/token/entity"
+	imageEntity "github.com/rl404/image-randomizer/internal/domain/image/entity"
 	"github.com/rl404/image-randomizer/internal/domain/user/entity"
 	"github.com/rl404/image-randomizer/internal/errors"
 	"github.com/rl404/image-randomizer/internal/utils"
 )
 
+// Registration modes.
+const (
+	RegisterOpen   = "open"
+	RegisterInvite = "invite"
+	RegisterClosed = "closed"
+)
+
+// RegisterConfig is registration config.
+type RegisterConfig struct {
+	Mode string
+	// Usernames which can't be registered because
+	// they may conflict with routes or mislead users.
+	ReservedUsernames []string
+	// Proof-of-work leading zero bits.
+	// Set 0 to disable.
+	ChallengeDifficulty int
+	ChallengeExpired    time.Duration
+}
+
+// RegisterChallenge is registration proof-of-work challenge.
+// Find nonce so that sha256(challenge + nonce) has at least
+// difficulty leading zero bits.
+type RegisterChallenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+// GetRegisterChallenge to get registration
+// proof-of-work challenge.
+func (s *service) GetRegisterChallenge(ctx context.Context) (*RegisterChallenge, int, error) {
+	if s.pow == nil {
+		return nil, http.StatusNotFound, stack.Wrap(ctx, errors.ErrDisabledChallenge)
+	}
+
+	challenge, err := s.pow.Create(s.cfg.Register.ChallengeDifficulty, s.cfg.Register.ChallengeExpired)
+	if err != nil {
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+	}
+
+	return &RegisterChallenge{
+		Challenge:  challenge.Challenge,
+		Difficulty: challenge.Difficulty,
+		ExpiredAt:  challenge.ExpiredAt,
+	}, http.StatusOK, nil
+}
+
+// verifyRegisterChallenge to verify proof-of-work
+// challenge and prevent it from being reused.
+func (s *service) verifyRegisterChallenge(ctx context.Context, challenge, nonce string) (int, error) {
+	if s.pow == nil {
+		return http.StatusOK, nil
+	}
+
+	if challenge == "" {
+		return http.StatusBadRequest, stack.Wrap(ctx, errors.ErrRequiredField("challenge"))
+	}
+
+	c, err := s.pow.Verify(challenge, nonce)
+	if err != nil {
+		return http.StatusBadRequest, stack.Wrap(ctx, err, errors.ErrInvalidChallenge)
+	}
+
+	// Challenge from previous difficulty config.
+	if c.Difficulty < s.cfg.Register.ChallengeDifficulty {
+		return http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidChallenge)
+	}
+
+	if code, err := s.challenge.Use(ctx, c.ID, c.ExpiredAt); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return http.StatusOK, nil
+}
+
 // RegisterRequest is register request model.
 type RegisterRequest struct {
-	Username string `json:"username" validate:"required" mod:"trim,lcase"`
+	Username string `json:"username" validate:"required,username" mod:"trim,lcase"`
 	Password string `json:"password" validate:"required" mod:"trim"`
+	// Required if registration is invite-only.
+	InviteCode string `json:"invite_code" mod:"trim,lcase"`
+	// Required if registration challenge is enabled.
+	Challenge string `json:"challenge" mod:"trim"`
+	Nonce     string `json:"nonce"`
+	IP        string `json:"-" swaggerignore:"true"`
+	UserAgent string `json:"-" swaggerignore:"true"`
 }
 
 // Register to register user.
 func (s *service) Register(ctx context.Context, data RegisterRequest) (*Token, int, error) {
+	if s.cfg.Register.Mode == RegisterClosed {
+		return nil, http.StatusForbidden, stack.Wrap(ctx, errors.ErrRegistrationClosed)
+	}
+
 	if err := utils.Validate(&data); err != nil {
 		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
 	}
 
+	if code, err := s.verifyRegisterChallenge(ctx, data.Challenge, data.Nonce); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if s.isReservedUsername(data.Username) {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrReservedUsername)
+	}
+
 	// Check duplicate username.
 	userTmp, code, err := s.user.GetByUsername(ctx, data.Username)
 	if err != nil && code != http.StatusNotFound {
@@ -35,99 +131,299 @@ func (s *service) Register(ctx context.Context, data RegisterRequest) (*Token, i
 		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrDuplicateUsername)
 	}
 
-	// Prepare password salt.
-	salt := utils.GenerateUUID()
+	if s.cfg.Register.Mode == RegisterInvite && data.InviteCode == "" {
+		return nil, http.StatusForbidden, stack.Wrap(ctx, errors.ErrInvalidInvite)
+	}
 
-	// Create user.
-	user, code, err := s.user.Create(ctx, entity.User{
-		Username:     data.Username,
-		PasswordHash: utils.EncodePassword(data.Password, salt),
-		PasswordSalt: salt,
-	})
+	// Hash password.
+	passwordHash, err := s.password.Hash(data.Password)
 	if err != nil {
-		return nil, code, stack.Wrap(ctx, err)
+		return nil, http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
 	}
 
-	// Create access token.
-	accessToken, code, err := s.token.CreateAccessToken(ctx, tokenEntity.CreateAccessTokenRequest{
-		UserID:     user.ID,
-		AccessUUID: utils.GenerateUUID(),
-	})
-	if err != nil {
+	// Use invite code and create user in one transaction
+	// so failed registration doesn't waste the code.
+	var user *entity.User
+	if code, err := s.transactor.Transaction(ctx, func(ctx context.Context) (int, error) {
+		if s.cfg.Register.Mode == RegisterInvite {
+			if code, err := s.invite.Use(ctx, data.InviteCode); err != nil {
+				return code, stack.Wrap(ctx, err)
+			}
+		}
+
+		var code int
+		var err error
+		user, code, err = s.user.Create(ctx, entity.User{
+			Username:     data.Username,
+			PasswordHash: passwordHash,
+		})
+		if err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		return code, nil
+	}); err != nil {
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
-	// Create refresh token.
-	refreshToken, code, err := s.token.CreateRefreshToken(ctx, tokenEntity.CreateRefreshTokenRequest{
-		UserID:      user.ID,
-		RefreshUUID: utils.GenerateUUID(),
-	})
+	// Create access and refresh token.
+	token, code, err := s.createToken(ctx, user.ID, utils.GenerateUUID(), data.IP, data.UserAgent)
 	if err != nil {
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
-	return &Token{
-		AccessToken:  accessToken.AccessToken,
-		RefreshToken: refreshToken.RefreshToken,
-	}, http.StatusCreated, nil
+	return token, http.StatusCreated, nil
+}
+
+func (s *service) isReservedUsername(username string) bool {
+	for _, reserved := range s.cfg.Register.ReservedUsernames {
+		if strings.EqualFold(username, reserved) {
+			return true
+		}
+	}
+	return false
 }
 
 // LoginRequest is login request model.
 type LoginRequest struct {
-	Username string `json:"username" validate:"required" mod:"trim,lcase"`
-	Password string `json:"password" validate:"required" mod:"trim"`
+	Username  string `json:"username" validate:"required" mod:"trim,lcase"`
+	Password  string `json:"password" validate:"required" mod:"trim"`
+	IP        string `json:"-" swaggerignore:"true"`
+	UserAgent string `json:"-" swaggerignore:"true"`
 }
 
-// Login to login user.
+// Login to login user. Will return challenge token
+// instead if the user has enabled two-factor
+// authentication.
 func (s *service) Login(ctx context.Context, data LoginRequest) (*Token, int, error) {
 	if err := utils.Validate(&data); err != nil {
 		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
 	}
 
+	// Check failed login attempts.
+	attemptKeys := s.getLoginAttemptKeys(data.Username, data.IP)
+	if code, err := s.checkLoginAttempt(ctx, attemptKeys); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
 	// Get user.
 	user, code, err := s.user.GetByUsername(ctx, data.Username)
 	if err != nil {
 		if code == http.StatusNotFound {
-			return nil, http.StatusNotFound, stack.Wrap(ctx, errors.ErrInvalidLogin)
+			return nil, http.StatusNotFound, stack.Wrap(ctx, errors.ErrInvalidLogin, s.failLogin(ctx, attemptKeys, errors.ErrInvalidLogin))
 		}
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
-	if user.PasswordHash != utils.EncodePassword(data.Password, user.PasswordSalt) {
-		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidLogin)
+	if user.Deleted {
+		return nil, http.StatusNotFound, stack.Wrap(ctx, errors.ErrInvalidLogin, s.failLogin(ctx, attemptKeys, errors.ErrInvalidLogin))
+	}
+
+	ok, rehash := s.password.Verify(data.Password, user.PasswordHash, user.PasswordSalt)
+	if !ok {
+		return nil, http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidLogin, s.failLogin(ctx, attemptKeys, errors.ErrInvalidLogin))
 	}
 
-	// Create access token.
-	accessToken, code, err := s.token.CreateAccessToken(ctx, tokenEntity.CreateAccessTokenRequest{
-		UserID:     user.ID,
-		AccessUUID: utils.GenerateUUID(),
-	})
+	s.passLogin(ctx, attemptKeys)
+
+	// Upgrade legacy or outdated password hash.
+	// Should not block login if failed.
+	if rehash {
+		if passwordHash, err := s.password.Hash(data.Password); err != nil {
+			utils.Error(stack.Wrap(ctx, err).Error())
+		} else if _, err := s.user.Update(ctx, entity.User{
+			ID:           user.ID,
+			Username:     user.Username,
+			PasswordHash: passwordHash,
+		}); err != nil {
+			utils.Error(stack.Wrap(ctx, err).Error())
+		}
+	}
+
+	// Require two-factor code if enabled.
+	challenge, code, err := s.createChallengeToken(ctx, user.ID)
 	if err != nil {
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
-	// Create refresh token.
-	refreshToken, code, err := s.token.CreateRefreshToken(ctx, tokenEntity.CreateRefreshTokenRequest{
-		UserID:      user.ID,
-		RefreshUUID: utils.GenerateUUID(),
-	})
+	if challenge != nil {
+		return challenge, http.StatusOK, nil
+	}
+
+	// Create access and refresh token.
+	token, code, err := s.createToken(ctx, user.ID, utils.GenerateUUID(), data.IP, data.UserAgent)
 	if err != nil {
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
-	return &Token{
-		AccessToken:  accessToken.AccessToken,
-		RefreshToken: refreshToken.RefreshToken,
-	}, http.StatusOK, nil
+	return token, http.StatusOK, nil
+}
+
+// ChangePasswordRequest is change password request model.
+type ChangePasswordRequest struct {
+	UserID   int64  `json:"-" validate:"required" swaggerignore:"true"`
+	FamilyID string `json:"-" swaggerignore:"true"`
+	// Empty if user has no password yet (registered with oidc).
+	OldPassword string `json:"old_password" mod:"trim"`
+	NewPassword string `json:"new_password" validate:"required" mod:"trim"`
+}
+
+// ChangePassword to change user password and
+// revoke the other sessions. User without password
+// can use it to set their initial password.
+func (s *service) ChangePassword(ctx context.Context, data ChangePasswordRequest) (int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	user, code, err := s.user.GetByID(ctx, data.UserID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.verifyCurrentPassword(ctx, user, data.OldPassword); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	passwordHash, err := s.password.Hash(data.NewPassword)
+	if err != nil {
+		return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalServer)
+	}
+
+	if code, err := s.user.Update(ctx, entity.User{
+		ID:           user.ID,
+		Username:     user.Username,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	// Revoke other sessions.
+	sessions, code, err := s.token.GetSessions(ctx, user.ID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	for _, session := range sessions {
+		if session.ID == data.FamilyID {
+			continue
+		}
+
+		if code, err := s.revokeFamily(ctx, session.ID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// DeleteUserRequest is delete user request model.
+type DeleteUserRequest struct {
+	UserID int64 `json:"-" validate:"required" swaggerignore:"true"`
+	// Empty if user has no password (registered with oidc).
+	Password string `json:"password" mod:"trim"`
+}
+
+// DeleteUser to soft-delete user, delete
+// their images, and revoke all tokens and api keys.
+func (s *service) DeleteUser(ctx context.Context, data DeleteUserRequest) (int, error) {
+	if err := utils.Validate(&data); err != nil {
+		return http.StatusBadRequest, stack.Wrap(ctx, err)
+	}
+
+	user, code, err := s.user.GetByID(ctx, data.UserID)
+	if err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.verifyCurrentPassword(ctx, user, data.Password); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	// Delete user data in one transaction so
+	// failed deletion doesn't leave partial data.
+	if code, err := s.transactor.Transaction(ctx, func(ctx context.Context) (int, error) {
+		if code, err := s.image.DeleteByUserID(ctx, user.ID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		if code, err := s.user.Delete(ctx, *user); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		if code, err := s.apiKey.DeleteByUserID(ctx, user.ID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		if code, err := s.oidc.DeleteIdentityByUserID(ctx, user.ID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		if code, err := s.totp.Delete(ctx, user.ID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		if code, err := s.hotlink.DeleteByUserID(ctx, user.ID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		if code, err := s.quota.DeleteByUserID(ctx, user.ID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		if code, err := s.view.DeleteByUserID(ctx, user.ID); err != nil {
+			return code, stack.Wrap(ctx, err)
+		}
+
+		return http.StatusOK, nil
+	}); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	if code, err := s.LogoutAll(ctx, user.ID); err != nil {
+		return code, stack.Wrap(ctx, err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// verifyCurrentPassword to re-check user password before
+// sensitive action. User registered with oidc has no
+// password and is already authenticated by the provider.
+func (s *service) verifyCurrentPassword(ctx context.Context, user *entity.User, password string) (int, error) {
+	if user.PasswordHash == "" {
+		return http.StatusOK, nil
+	}
+
+	if password == "" {
+		return http.StatusBadRequest, stack.Wrap(ctx, errors.ErrRequiredField("password"))
+	}
+
+	if ok, _ := s.password.Verify(password, user.PasswordHash, user.PasswordSalt); !ok {
+		return http.StatusBadRequest, stack.Wrap(ctx, errors.ErrInvalidPassword)
+	}
+
+	return http.StatusOK, nil
 }
 
 type usernameValidation struct {
 	Username string `validate:"required" mod:"trim,lcase"`
 }
 
+// GetRandomImageRequest is get random image request model.
+type GetRandomImageRequest struct {
+	Username string
+	// Contains list token or signed url for
+	// unlisted and private list.
+	Query     url.Values
+	Origin    string
+	Referer   string
+	UserAgent string
+}
+
 // GetRandomImage to get random image.
-func (s *service) GetRandomImage(ctx context.Context, username string) (io.ReadCloser, int, error) {
-	u := usernameValidation{Username: username}
+func (s *service) GetRandomImage(ctx context.Context, data GetRandomImageRequest) (io.ReadCloser, int, error) {
+	u := usernameValidation{Username: data.Username}
 	if err := utils.Validate(&u); err != nil {
 		return nil, http.StatusBadRequest, stack.Wrap(ctx, err)
 	}
@@ -137,21 +433,68 @@ func (s *service) GetRandomImage(ctx context.Context, username string) (io.ReadC
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
+	if user.Deleted {
+		return nil, http.StatusGone, stack.Wrap(ctx, errors.ErrDeletedUser)
+	}
+
+	if user.Suspended {
+		return nil, http.StatusForbidden, stack.Wrap(ctx, errors.ErrSuspendedUser)
+	}
+
+	// Same as not found so hidden list can't be enumerated.
+	if !s.canAccessList(user, data.Query) {
+		return nil, http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundUser)
+	}
+
+	if code, err := s.checkBandwidthQuota(ctx, user.ID); err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	placeholder, code, err := s.checkHotlink(ctx, user.ID, data.Origin, data.Referer)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	if placeholder != "" {
+		img, code, err := s.downloadImage(ctx, placeholder)
+		if err != nil {
+			return nil, code, stack.Wrap(ctx, err)
+		}
+		return s.meterImage(ctx, user.ID, img), http.StatusOK, nil
+	}
+
 	images, code, err := s.image.Get(ctx, user.ID)
 	if err != nil {
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
-	if len(images) == 0 {
+	policy, code, err := s.domainPolicy.GetPolicy(ctx)
+	if err != nil {
+		return nil, code, stack.Wrap(ctx, err)
+	}
+
+	// Skip images blocked by newer domain rules.
+	allowedImages := make([]*imageEntity.Image, 0, len(images))
+	for _, img := range images {
+		if policy.IsAllowed(img.Image) {
+			allowedImages = append(allowedImages, img)
+		}
+	}
+
+	if len(allowedImages) == 0 {
 		return nil, http.StatusNotFound, stack.Wrap(ctx, errors.ErrNotFoundImage)
 	}
 
-	randIndex := rand.New(rand.NewSource(time.Now().UnixNano())).Intn(len(images))
+	randIndex := rand.New(rand.NewSource(time.Now().UnixNano())).Intn(len(allowedImages))
 
-	img, code, err := s.image.Download(ctx, images[randIndex].Image)
+	randImage := allowedImages[randIndex]
+
+	img, code, err := s.downloadImage(ctx, randImage.Image)
 	if err != nil {
 		return nil, code, stack.Wrap(ctx, err)
 	}
 
-	return img, http.StatusOK, nil
+	s.recordView(ctx, user.ID, randImage.ID, data.Origin, data.Referer, data.UserAgent)
+
+	return s.meterImage(ctx, user.ID, img), http.StatusOK, nil
 }
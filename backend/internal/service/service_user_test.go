@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	_errors "errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	challengeCache "github.com/rl404/image-randomizer/internal/domain/challenge/repository/cache"
+	totpEntity "github.com/rl404/image-randomizer/internal/domain/totp/entity"
+	userEntity "github.com/rl404/image-randomizer/internal/domain/user/entity"
+	userRepository "github.com/rl404/image-randomizer/internal/domain/user/repository"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"github.com/rl404/image-randomizer/pkg/cache"
+	"github.com/rl404/image-randomizer/pkg/password"
+	"github.com/rl404/image-randomizer/pkg/pow"
+)
+
+const testChallengeDifficulty = 8
+
+func newRegisterTest(t *testing.T) *service {
+	t.Helper()
+
+	store, err := cache.NewStore(cache.InMemory, "", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return &service{
+		challenge: challengeCache.New(store),
+		pow:       pow.New("secret"),
+		cfg: Config{Register: RegisterConfig{
+			Mode:                RegisterOpen,
+			ChallengeDifficulty: testChallengeDifficulty,
+			ChallengeExpired:    time.Minute,
+		}},
+	}
+}
+
+// solve to find the nonce of the challenge.
+func solve(challenge string, difficulty int) string {
+	for i := 0; ; i++ {
+		nonce := strconv.Itoa(i)
+		if pow.LeadingZeroBits(pow.Hash(challenge, nonce)) >= difficulty {
+			return nonce
+		}
+	}
+}
+
+func TestRegisterChallengeRejected(t *testing.T) {
+	s := newRegisterTest(t)
+	p := pow.New("secret")
+
+	valid, err := p.Create(testChallengeDifficulty, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := p.Create(testChallengeDifficulty, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	easy, err := p.Create(0, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged, err := pow.New("other-secret").Create(testChallengeDifficulty, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		challenge string
+		nonce     string
+		wantErr   error
+	}{
+		{
+			name: "missing challenge",
+		},
+		{
+			name:      "wrong nonce",
+			challenge: valid.Challenge,
+			nonce:     "wrong",
+			wantErr:   errors.ErrInvalidChallenge,
+		},
+		{
+			name:      "expired challenge",
+			challenge: expired.Challenge,
+			nonce:     solve(expired.Challenge, testChallengeDifficulty),
+			wantErr:   errors.ErrInvalidChallenge,
+		},
+		{
+			name:      "lower difficulty",
+			challenge: easy.Challenge,
+			nonce:     "0",
+			wantErr:   errors.ErrInvalidChallenge,
+		},
+		{
+			name:      "forged signature",
+			challenge: forged.Challenge,
+			nonce:     solve(forged.Challenge, testChallengeDifficulty),
+			wantErr:   errors.ErrInvalidChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Nonce "wrong" may solve the challenge by chance.
+			if tt.nonce == "wrong" && pow.LeadingZeroBits(pow.Hash(tt.challenge, tt.nonce)) >= testChallengeDifficulty {
+				t.Skip("nonce solves the challenge")
+			}
+
+			_, code, err := s.Register(context.Background(), RegisterRequest{
+				Username:  "user",
+				Password:  "password",
+				Challenge: tt.challenge,
+				Nonce:     tt.nonce,
+			})
+			if code != http.StatusBadRequest {
+				t.Errorf("code = %d, want %d", code, http.StatusBadRequest)
+			}
+
+			if tt.wantErr != nil && !_errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterChallengeReused(t *testing.T) {
+	s := newRegisterTest(t)
+	ctx := context.Background()
+
+	challenge, _, err := s.GetRegisterChallenge(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := solve(challenge.Challenge, challenge.Difficulty)
+
+	if _, err := s.verifyRegisterChallenge(ctx, challenge.Challenge, nonce); err != nil {
+		t.Fatalf("first use: err = %v", err)
+	}
+
+	if _, err := s.verifyRegisterChallenge(ctx, challenge.Challenge, nonce); !_errors.Is(err, errors.ErrUsedChallenge) {
+		t.Errorf("second use: err = %v, want %v", err, errors.ErrUsedChallenge)
+	}
+}
+
+// Only the methods used by login flow are
+// implemented. Calling the others will panic.
+type fakeLoginUserRepo struct {
+	userRepository.Repository
+	user    userEntity.User
+	updated []userEntity.User
+}
+
+func (f *fakeLoginUserRepo) GetByUsername(_ context.Context, username string) (*userEntity.User, int, error) {
+	if username != f.user.Username {
+		return nil, http.StatusNotFound, errors.ErrNotFoundUser
+	}
+	u := f.user
+	return &u, http.StatusOK, nil
+}
+
+func (f *fakeLoginUserRepo) Update(_ context.Context, data userEntity.User) (int, error) {
+	f.updated = append(f.updated, data)
+	return http.StatusOK, nil
+}
+
+func TestLoginPasswordHash(t *testing.T) {
+	hasher := password.New(password.Config{
+		LegacyPepper: "legacy-pepper",
+		Memory:       1024,
+		Iterations:   1,
+		Parallelism:  1,
+	})
+
+	argon2Hash, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sha1Hash := sha1.Sum([]byte("salt" + "password" + "legacy-pepper"))
+	legacyHash := hex.EncodeToString(sha1Hash[:])
+
+	tests := []struct {
+		name        string
+		hash        string
+		salt        string
+		password    string
+		wantErr     error
+		wantUpgrade bool
+	}{
+		{
+			name:        "upgrade legacy hash",
+			hash:        legacyHash,
+			salt:        "salt",
+			password:    "password",
+			wantUpgrade: true,
+		},
+		{
+			name:     "wrong password with legacy hash",
+			hash:     legacyHash,
+			salt:     "salt",
+			password: "wrong-password",
+			wantErr:  errors.ErrInvalidLogin,
+		},
+		{
+			name:     "current hash",
+			hash:     argon2Hash,
+			password: "password",
+		},
+		{
+			name:     "wrong password",
+			hash:     argon2Hash,
+			password: "wrong-password",
+			wantErr:  errors.ErrInvalidLogin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeLoginUserRepo{user: userEntity.User{
+				ID:           1,
+				Username:     "user",
+				PasswordHash: tt.hash,
+				PasswordSalt: tt.salt,
+			}}
+
+			s := newAttemptTest(t, LoginLimitConfig{FreeFailures: 10, Window: time.Minute})
+			s.user = users
+			s.password = hasher
+			// Stop at challenge token so no access token is created.
+			s.totp = &fakeTOTPRepo{totps: map[int64]totpEntity.TOTP{1: {UserID: 1, Enabled: true}}}
+			s.token = &fakeTokenRepo{}
+
+			_, _, err := s.Login(context.Background(), LoginRequest{
+				Username: "user",
+				Password: tt.password,
+			})
+			if !_errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			if !tt.wantUpgrade {
+				if len(users.updated) > 0 {
+					t.Errorf("updated = %+v, want none", users.updated)
+				}
+				return
+			}
+
+			if len(users.updated) != 1 {
+				t.Fatalf("updated %d times, want 1", len(users.updated))
+			}
+
+			newHash := users.updated[0].PasswordHash
+			if ok, rehash := hasher.Verify(tt.password, newHash, ""); !ok || rehash {
+				t.Errorf("new hash %s: Verify() = %v, %v, want true, false", newHash, ok, rehash)
+			}
+		})
+	}
+}
@@ -1,8 +1,19 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
 	"strings"
+	"time"
+
+	"github.com/rl404/fairy/cache"
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"golang.org/x/sync/singleflight"
 )
 
 // GetKey to generate cache key.
@@ -15,3 +26,95 @@ func GetKey(params ...interface{}) string {
 	}
 	return strings.Join(strParams, ":")
 }
+
+// CacheLoader is cache wrapper with request coalescing
+// for cache misses and probabilistic early expiration
+// for hot keys.
+type CacheLoader struct {
+	cacher cache.Cacher
+	ttl    time.Duration
+	beta   float64
+	group  singleflight.Group
+}
+
+// NewCacheLoader to create new cache loader.
+// Beta higher than 1 favors earlier refresh.
+func NewCacheLoader(cacher cache.Cacher, ttl time.Duration) *CacheLoader {
+	return &CacheLoader{
+		cacher: cacher,
+		ttl:    ttl,
+		beta:   1,
+	}
+}
+
+type cacheEntry struct {
+	Data   json.RawMessage `json:"data"`
+	Delta  time.Duration   `json:"delta"`
+	Expiry time.Time       `json:"expiry"`
+}
+
+type cacheResult struct {
+	data interface{}
+	code int
+}
+
+// LoadCache to get data from cache. If the data is not in
+// cache, concurrent calls with the same key share one call
+// to fn and the result is saved to cache. If the cached data
+// is about to expire, it is refreshed in background while
+// the cached data is returned.
+func LoadCache[T any](ctx context.Context, l *CacheLoader, key string, fn func(context.Context) (T, int, error)) (data T, code int, err error) {
+	var e cacheEntry
+	if l.cacher.Get(ctx, key, &e) == nil && len(e.Data) > 0 && json.Unmarshal(e.Data, &data) == nil {
+		if l.shouldRefresh(e) {
+			l.group.DoChan(key, func() (interface{}, error) {
+				return loadCache(context.WithoutCancel(ctx), l, key, fn)
+			})
+		}
+		return data, http.StatusOK, nil
+	}
+
+	// Caller's context should not cancel the load
+	// for the others waiting for the same key.
+	res, err, _ := l.group.Do(key, func() (interface{}, error) {
+		return loadCache(context.WithoutCancel(ctx), l, key, fn)
+	})
+
+	r := res.(cacheResult)
+	if err != nil {
+		return data, r.code, stack.Wrap(ctx, err)
+	}
+
+	return r.data.(T), r.code, nil
+}
+
+func loadCache[T any](ctx context.Context, l *CacheLoader, key string, fn func(context.Context) (T, int, error)) (interface{}, error) {
+	start := time.Now()
+
+	data, code, err := fn(ctx)
+	if err != nil {
+		return cacheResult{code: code}, err
+	}
+
+	d, err := json.Marshal(data)
+	if err != nil {
+		return cacheResult{code: http.StatusInternalServerError}, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	if err := l.cacher.Set(ctx, key, cacheEntry{
+		Data:   d,
+		Delta:  time.Since(start),
+		Expiry: time.Now().Add(l.ttl),
+	}, l.ttl); err != nil {
+		return cacheResult{code: http.StatusInternalServerError}, stack.Wrap(ctx, err, errors.ErrInternalCache)
+	}
+
+	return cacheResult{data: data, code: code}, nil
+}
+
+// shouldRefresh is XFetch algorithm.
+// https://cseweb.ucsd.edu/~avattani/papers/cache_stampede.pdf
+func (l *CacheLoader) shouldRefresh(e cacheEntry) bool {
+	gap := time.Duration(float64(e.Delta) * l.beta * -math.Log(1-rand.Float64()))
+	return !time.Now().Add(gap).Before(e.Expiry)
+}
@@ -0,0 +1,172 @@
+// Package dbtest provides database for testing
+// repository database layer.
+package dbtest
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// EnvDSN is env containing postgres dsn for database test,
+// for example "host=localhost port=5432 user=postgres dbname=test".
+const EnvDSN = "IR_TEST_DB_DSN"
+
+// Same naming strategy as the server.
+var namingStrategy = schema.NamingStrategy{
+	SingularTable: true,
+}
+
+// Open to open postgres database from EnvDSN and migrate
+// the models in a new schema which is dropped after the
+// test. The test is skipped if EnvDSN is empty.
+func Open(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(EnvDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", EnvDSN)
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	name := "test_" + hex.EncodeToString(b)
+
+	admin, err := open(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := admin.Exec("create schema " + name).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := open(dsn + " search_path=" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if tmp, err := db.DB(); err == nil {
+			tmp.Close()
+		}
+		admin.Exec("drop schema " + name + " cascade")
+		if tmp, err := admin.DB(); err == nil {
+			tmp.Close()
+		}
+	})
+
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func open(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		NamingStrategy: namingStrategy,
+	})
+}
+
+// Recorder is gorm logger which records executed sql.
+type Recorder struct {
+	logger.Interface
+
+	mu   sync.Mutex
+	sqls []string
+}
+
+// Trace to record the sql.
+func (r *Recorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sqls = append(r.sqls, sql)
+}
+
+// SQL to get recorded sql containing the substring.
+func (r *Recorder) SQL(substr string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var res []string
+	for _, s := range r.sqls {
+		if strings.Contains(s, substr) {
+			res = append(res, s)
+		}
+	}
+
+	return res
+}
+
+// DryRun to open postgres database which only generates
+// the sql without connecting to the database. Useful to
+// check the generated sql when EnvDSN is not available.
+func DryRun(t *testing.T) (*gorm.DB, *Recorder) {
+	t.Helper()
+
+	rec := &Recorder{Interface: logger.Discard}
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &dryRunConn{}}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               rec,
+		NamingStrategy:       namingStrategy,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, rec
+}
+
+var errDryRun = errors.New("dry run")
+
+// dryRunConn is connection which supports
+// transaction but never executes anything.
+type dryRunConn struct{}
+
+func (*dryRunConn) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+
+func (*dryRunConn) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, errDryRun
+}
+
+func (*dryRunConn) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+
+func (*dryRunConn) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func (c *dryRunConn) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return c, nil
+}
+
+func (*dryRunConn) Commit() error {
+	return nil
+}
+
+func (*dryRunConn) Rollback() error {
+	return nil
+}
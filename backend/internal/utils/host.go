@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// GetURLHost to get lowercase hostname of the url
+// without trailing dot. Will return empty string if invalid.
+func GetURLHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return NormalizeHost(u.Hostname())
+}
+
+// NormalizeHost to lowercase the host and remove the
+// trailing dot so example.com. is the same as example.com.
+func NormalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// MatchHost to check if host matches any of the patterns.
+// Pattern *.example.com matches subdomains but not the
+// example.com itself.
+func MatchHost(patterns []string, host string) bool {
+	host = NormalizeHost(host)
+	for _, p := range patterns {
+		if suffix, ok := strings.CutPrefix(p, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+
+		if p == host {
+			return true
+		}
+	}
+	return false
+}
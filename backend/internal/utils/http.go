@@ -3,8 +3,11 @@ package utils
 import (
 	"context"
 	"encoding/json"
+	_errors "errors"
 	"fmt"
 	"io"
+	"math"
+	"net"
 	"net/http"
 	"runtime/debug"
 	"strconv"
@@ -36,6 +39,11 @@ func ResponseWithJSON(w http.ResponseWriter, code int, data interface{}, err err
 	rJSON, _ := json.Marshal(r)
 
 	// Set response header.
+	var retryErr *errors.RetryAfterError
+	if _errors.As(err, &retryErr) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.RetryAfter.Seconds()))))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Content-Length", strconv.Itoa(len(rJSON)))
 	w.WriteHeader(code)
@@ -53,6 +61,17 @@ func ResponseWithImage(ctx context.Context, w http.ResponseWriter, image io.Read
 	}
 }
 
+// GetIP to get client ip from request.
+// Use with middleware.RealIP to respect
+// X-Real-IP and X-Forwarded-For header.
+func GetIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // Recoverer is custom recoverer middleware.
 // Will return 500.
 func Recoverer(next http.Handler) http.Handler {
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/rl404/image-randomizer/pkg/jwk"
+)
+
+// GenerateJWT to generate signed jwt token with additional
+// claims. Will be signed using key set signing key if exists.
+// Otherwise, will use HS256 with the secret.
+func GenerateJWT(keys *jwk.KeySet, secret string, expired time.Duration, claims map[string]interface{}) (string, error) {
+	claim := jwt.MapClaims{}
+	claim["authorized"] = true
+	for k, v := range claims {
+		claim[k] = v
+	}
+	claim["iat"] = time.Now().UTC().Unix()
+	claim["exp"] = time.Now().UTC().Add(expired).Unix()
+
+	if keys.CanSign() {
+		return keys.Sign(claim)
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claim).SignedString([]byte(secret))
+}
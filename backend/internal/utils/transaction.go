@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rl404/fairy/errors/stack"
+	"github.com/rl404/image-randomizer/internal/errors"
+	"gorm.io/gorm"
+)
+
+type ctxTransaction struct{}
+
+type transaction struct {
+	db          *gorm.DB
+	afterCommit []func(ctx context.Context) error
+}
+
+// Transactor is database transaction runner which
+// lets repositories of different domains join
+// the same transaction through context.
+type Transactor struct {
+	db *gorm.DB
+}
+
+// NewTransactor to create new database transactor.
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{
+		db: db,
+	}
+}
+
+// Transaction to run fn in one database transaction.
+// Functions registered with AfterCommit are called
+// after the transaction is committed.
+func (t *Transactor) Transaction(ctx context.Context, fn func(ctx context.Context) (int, error)) (int, error) {
+	tx := &transaction{}
+	code := http.StatusOK
+
+	if err := t.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		tx.db = db
+
+		var err error
+		code, err = fn(context.WithValue(ctx, ctxTransaction{}, tx))
+		return err
+	}); err != nil {
+		if code < http.StatusBadRequest {
+			return http.StatusInternalServerError, stack.Wrap(ctx, err, errors.ErrInternalDB)
+		}
+		return code, stack.Wrap(ctx, err)
+	}
+
+	for _, fn := range tx.afterCommit {
+		if err := fn(ctx); err != nil {
+			Error(stack.Wrap(ctx, err).Error())
+		}
+	}
+
+	return code, nil
+}
+
+// GetDB to get database of the transaction in
+// the context. Will use the db if there is none.
+func GetDB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(ctxTransaction{}).(*transaction); ok {
+		return tx.db
+	}
+	return db.WithContext(ctx)
+}
+
+// AfterCommit to call fn after the transaction in
+// the context is committed, for example to invalidate
+// cache. Will be called right away if there is none.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(ctxTransaction{}).(*transaction); ok {
+		tx.afterCommit = append(tx.afterCommit, fn)
+		return nil
+	}
+	return fn(ctx)
+}
@@ -1,8 +1,6 @@
 package utils
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
 	"strings"
 
 	"github.com/google/uuid"
@@ -12,12 +10,3 @@ import (
 func GenerateUUID() string {
 	return strings.Replace(uuid.New().String(), "-", "", -1)
 }
-
-const passwordPepper string = "yagoobestgirl"
-
-// EncodePassword to encode password with salt and pepper.
-func EncodePassword(str, salt string) string {
-	hasher := sha1.New()
-	hasher.Write([]byte(salt + str + passwordPepper))
-	return hex.EncodeToString(hasher.Sum(nil))
-}
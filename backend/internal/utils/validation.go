@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"regexp"
 	"strings"
 
 	"github.com/rl404/fairy/validation"
@@ -12,9 +13,19 @@ import (
 
 var val validation.Validator
 
+// Username is 3-32 characters of lowercase letter, number,
+// underscore and dash, started with letter or number.
+var usernameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{2,31}$`)
+
+// Host pattern is lowercase hostname with optional
+// leading wildcard for subdomains.
+var hostPatternRegex = regexp.MustCompile(`^(\*\.)?[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
 func init() {
 	val = playground.New(true)
 	val.RegisterModifier("no_space", modNoSpace)
+	val.RegisterValidator("username", valUsername)
+	val.RegisterValidator("host_pattern", valHostPattern)
 	val.RegisterValidatorError("required", valErrRequired)
 	val.RegisterValidatorError("gte", valErrGTE)
 	val.RegisterValidatorError("gt", valErrGT)
@@ -22,6 +33,8 @@ func init() {
 	val.RegisterValidatorError("lt", valErrLT)
 	val.RegisterValidatorError("url", valErrURL)
 	val.RegisterValidatorError("oneof", valErrOneOf)
+	val.RegisterValidatorError("username", valErrUsername)
+	val.RegisterValidatorError("host_pattern", valErrHostPattern)
 }
 
 // Validate to validate struct using validate tag.
@@ -34,6 +47,21 @@ func modNoSpace(in string, _ ...string) string {
 	return strings.ReplaceAll(in, " ", "")
 }
 
+// IsValidUsername to check username format.
+func IsValidUsername(username string) bool {
+	return usernameRegex.MatchString(username)
+}
+
+func valUsername(value interface{}, _ ...string) bool {
+	str, _ := value.(string)
+	return IsValidUsername(str)
+}
+
+func valHostPattern(value interface{}, _ ...string) bool {
+	str, _ := value.(string)
+	return len(str) <= 253 && hostPatternRegex.MatchString(str)
+}
+
 func valErrRequired(f string, param ...string) error {
 	return errors.ErrRequiredField(camelToSnake(f))
 }
@@ -62,6 +90,14 @@ func valErrOneOf(f string, param ...string) error {
 	return errors.ErrOneOfField(camelToSnake(f), param[0])
 }
 
+func valErrUsername(f string, param ...string) error {
+	return errors.ErrUsernameField(camelToSnake(f))
+}
+
+func valErrHostPattern(f string, param ...string) error {
+	return errors.ErrHostPatternField(camelToSnake(f))
+}
+
 func camelToSnake(name string) string {
 	if name == "" {
 		return ""
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rl404/fairy/cache"
+	"github.com/rl404/fairy/pubsub"
+)
+
+type invalidator struct {
+	cacher cache.Cacher
+	pubsub pubsub.PubSub
+	topic  string
+	source string
+}
+
+type invalidation struct {
+	Source string `json:"source"`
+	Key    string `json:"key"`
+}
+
+// NewInvalidator to wrap cacher so every deleted key is
+// published to the topic. Every instance subscribing to
+// the same topic will evict the key from its own cacher.
+// Key should be deleted after the data is committed, or
+// other instances may cache the old data again.
+func NewInvalidator(cacher cache.Cacher, ps pubsub.PubSub, topic string) (cache.Cacher, error) {
+	i := &invalidator{
+		cacher: cacher,
+		pubsub: ps,
+		topic:  topic,
+		source: uuid.NewString(),
+	}
+
+	if err := ps.Subscribe(context.Background(), topic, i.handle); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (i *invalidator) handle(ctx context.Context, message []byte) error {
+	var msg invalidation
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return err
+	}
+
+	// Already deleted when published.
+	if msg.Source == i.source {
+		return nil
+	}
+
+	return i.cacher.Delete(ctx, msg.Key)
+}
+
+// Get to get data from cache.
+func (i *invalidator) Get(ctx context.Context, key string, data interface{}) error {
+	return i.cacher.Get(ctx, key, data)
+}
+
+// Set to save data to cache.
+func (i *invalidator) Set(ctx context.Context, key string, data interface{}, ttl ...time.Duration) error {
+	return i.cacher.Set(ctx, key, data, ttl...)
+}
+
+// Delete to delete data from cache and
+// publish the invalidation to other instances.
+func (i *invalidator) Delete(ctx context.Context, key string) error {
+	if err := i.cacher.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	msg, err := json.Marshal(invalidation{
+		Source: i.source,
+		Key:    key,
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.pubsub.Publish(ctx, i.topic, msg)
+}
+
+// Close to close cache connection.
+func (i *invalidator) Close() error {
+	return i.cacher.Close()
+}
@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rl404/fairy/cache"
+	_redis "github.com/rl404/fairy/cache/redis"
+)
+
+// ErrNotFound is error for missing or expired key.
+var ErrNotFound = errors.New("key not found")
+
+// Store is cacher for state which must not be dropped
+// like regular cache, for example failed login attempt
+// and used challenge. Ttl is always respected.
+type Store interface {
+	cache.Cacher
+	// SetNX to save data only if the key doesn't exist.
+	// Will return false if the key already exists.
+	SetNX(ctx context.Context, key string, data interface{}, ttl time.Duration) (bool, error)
+	// Incr to increment integer data atomically and
+	// reset the ttl. Key is created if doesn't exist.
+	// Will return the value after the increment.
+	Incr(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error)
+}
+
+// NewStore to create new state store depends on the type.
+// NOP falls back to in-memory since the state can't be dropped.
+func NewStore(cacheType CacheType, address string, password string, expiredTime time.Duration) (Store, error) {
+	switch cacheType {
+	case NOP, InMemory:
+		return newMemoryStore(expiredTime), nil
+	case Redis:
+		return newRedisStore(address, password, expiredTime)
+	default:
+		return nil, ErrInvalidCacheType
+	}
+}
+
+type redisStore struct {
+	*_redis.Client
+	client *redis.Client
+}
+
+func newRedisStore(address, password string, expiredTime time.Duration) (*redisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     address,
+		Password: password,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+
+	return &redisStore{
+		Client: _redis.NewFromGoRedis(client, expiredTime),
+		client: client,
+	}, nil
+}
+
+// SetNX to save data only if the key doesn't exist.
+func (r *redisStore) SetNX(ctx context.Context, key string, data interface{}, ttl time.Duration) (bool, error) {
+	d, err := json.Marshal(data)
+	if err != nil {
+		return false, err
+	}
+	return r.client.SetNX(ctx, key, d, ttl).Result()
+}
+
+// Incr to increment integer data atomically and reset the ttl.
+func (r *redisStore) Incr(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	if _, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.IncrBy(ctx, key, n)
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+type memoryItem struct {
+	data      []byte
+	expiredAt time.Time
+}
+
+type memoryStore struct {
+	mu          sync.Mutex
+	items       map[string]memoryItem
+	expiredTime time.Duration
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func newMemoryStore(expiredTime time.Duration) *memoryStore {
+	m := &memoryStore{
+		items:       make(map[string]memoryItem),
+		expiredTime: expiredTime,
+		done:        make(chan struct{}),
+	}
+
+	go m.cleanup()
+
+	return m
+}
+
+// cleanup to remove expired keys periodically.
+func (m *memoryStore) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			for k, v := range m.items {
+				if now.After(v.expiredAt) {
+					delete(m.items, k)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// Get to get data from store.
+func (m *memoryStore) Get(_ context.Context, key string, data interface{}) error {
+	m.mu.Lock()
+	item, ok := m.items[key]
+	m.mu.Unlock()
+
+	if !ok || time.Now().After(item.expiredAt) {
+		return ErrNotFound
+	}
+
+	return json.Unmarshal(item.data, &data)
+}
+
+// Set to save data to store.
+func (m *memoryStore) Set(_ context.Context, key string, data interface{}, ttl ...time.Duration) error {
+	item, err := m.newItem(data, ttl...)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.items[key] = *item
+	m.mu.Unlock()
+
+	return nil
+}
+
+// SetNX to save data only if the key doesn't exist.
+func (m *memoryStore) SetNX(_ context.Context, key string, data interface{}, ttl time.Duration) (bool, error) {
+	item, err := m.newItem(data, ttl)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.items[key]; ok && time.Now().Before(old.expiredAt) {
+		return false, nil
+	}
+
+	m.items[key] = *item
+
+	return true, nil
+}
+
+// Incr to increment integer data atomically and reset the ttl.
+func (m *memoryStore) Incr(_ context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var value int64
+	if old, ok := m.items[key]; ok && time.Now().Before(old.expiredAt) {
+		if err := json.Unmarshal(old.data, &value); err != nil {
+			return 0, err
+		}
+	}
+
+	value += n
+
+	item, err := m.newItem(value, ttl)
+	if err != nil {
+		return 0, err
+	}
+
+	m.items[key] = *item
+
+	return value, nil
+}
+
+// Delete to delete data from store.
+func (m *memoryStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.items, key)
+	m.mu.Unlock()
+	return nil
+}
+
+// Close to stop the cleanup.
+func (m *memoryStore) Close() error {
+	m.closeOnce.Do(func() { close(m.done) })
+	return nil
+}
+
+func (m *memoryStore) newItem(data interface{}, ttl ...time.Duration) (*memoryItem, error) {
+	d, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	expiredTime := m.expiredTime
+	if len(ttl) > 0 {
+		expiredTime = ttl[0]
+	}
+
+	return &memoryItem{
+		data:      d,
+		expiredAt: time.Now().Add(expiredTime),
+	}, nil
+}
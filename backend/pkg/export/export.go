@@ -0,0 +1,113 @@
+// Package export writes rows as CSV or JSON lines so
+// large data can be streamed without loading all of
+// them to memory.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Format is export format.
+type Format string
+
+// Available export format.
+const (
+	CSV   Format = "csv"
+	JSONL Format = "jsonl"
+)
+
+// ErrInvalidFormat is error for invalid export format.
+var ErrInvalidFormat = errors.New("invalid export format")
+
+// Writer writes rows in the export format.
+type Writer struct {
+	format  Format
+	columns []string
+	csv     *csv.Writer
+	jsonl   *bufio.Writer
+}
+
+// New to create new export writer.
+// CSV header will be written immediately.
+func New(w io.Writer, format Format, columns []string) (*Writer, error) {
+	ew := Writer{
+		format:  format,
+		columns: columns,
+	}
+
+	switch format {
+	case CSV:
+		ew.csv = csv.NewWriter(w)
+		if err := ew.csv.Write(columns); err != nil {
+			return nil, err
+		}
+	case JSONL:
+		ew.jsonl = bufio.NewWriter(w)
+	default:
+		return nil, ErrInvalidFormat
+	}
+
+	return &ew, nil
+}
+
+// ContentType to get the content type of the format.
+func ContentType(format Format) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case JSONL:
+		return "application/x-ndjson"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Write to write a row. Values should be
+// in the same order as the columns.
+func (w *Writer) Write(values ...interface{}) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("got %d values for %d columns", len(values), len(w.columns))
+	}
+
+	if w.format == CSV {
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = fmt.Sprint(v)
+		}
+		return w.csv.Write(record)
+	}
+
+	// Manually encoded to keep the column order.
+	_ = w.jsonl.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			_ = w.jsonl.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(w.columns[i])
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		_, _ = w.jsonl.Write(key)
+		_ = w.jsonl.WriteByte(':')
+		_, _ = w.jsonl.Write(value)
+	}
+	_, err := w.jsonl.WriteString("}\n")
+	return err
+}
+
+// Flush to write buffered rows.
+func (w *Writer) Flush() error {
+	if w.format == CSV {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return w.jsonl.Flush()
+}
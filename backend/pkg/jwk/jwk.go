@@ -0,0 +1,261 @@
+// Package jwk manages asymmetric keys to sign and verify
+// jwt token, and to expose them as JSON Web Key Set.
+package jwk
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Errors.
+var (
+	ErrInvalidPEM       = errors.New("invalid pem file")
+	ErrUnsupportedKey   = errors.New("unsupported key type, only rsa and ed25519 are supported")
+	ErrNoSigningKey     = errors.New("no signing key")
+	ErrUnknownKeyID     = errors.New("unknown key id")
+	ErrInvalidAlgorithm = errors.New("invalid signing algorithm for the key")
+)
+
+// Key is a JSON Web Key.
+type Key struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+
+	// RSA.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Ed25519.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+
+	method    jwt.SigningMethod
+	publicKey crypto.PublicKey
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []Key `json:"keys"`
+}
+
+// KeySet contains one signing key and
+// multiple verification keys.
+type KeySet struct {
+	signingKey crypto.PrivateKey
+	signingKID string
+	keys       map[string]*Key
+	kids       []string
+}
+
+// New to create new key set from PEM files.
+// The private key is used for signing and its public key
+// is automatically added as verification key. Public key
+// files are additional verification keys, for example the
+// previous keys when rotating. Private key file is optional.
+func New(privateKeyFile string, publicKeyFiles []string) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key)}
+
+	if privateKeyFile != "" {
+		block, err := readPEM(privateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		privateKey, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ks.add(privateKey.(interface{ Public() crypto.PublicKey }).Public())
+		if err != nil {
+			return nil, err
+		}
+
+		ks.signingKey = privateKey
+		ks.signingKID = key.KeyID
+	}
+
+	for _, file := range publicKeyFiles {
+		if file == "" {
+			continue
+		}
+
+		block, err := readPEM(file)
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := ks.add(publicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return ks, nil
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+func (ks *KeySet) add(publicKey crypto.PublicKey) (*Key, error) {
+	var key Key
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		key = Key{
+			KeyType:   "RSA",
+			Use:       "sig",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			N:         base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			method:    jwt.SigningMethodRS256,
+			publicKey: k,
+		}
+	case ed25519.PublicKey:
+		key = Key{
+			KeyType:   "OKP",
+			Use:       "sig",
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(k),
+			method:    jwt.SigningMethodEdDSA,
+			publicKey: k,
+		}
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	key.KeyID = thumbprint(key)
+
+	if _, ok := ks.keys[key.KeyID]; !ok {
+		ks.keys[key.KeyID] = &key
+		ks.kids = append(ks.kids, key.KeyID)
+	}
+
+	return &key, nil
+}
+
+// thumbprint is RFC 7638 JWK thumbprint.
+func thumbprint(key Key) string {
+	var members interface{}
+	switch key.KeyType {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{key.E, key.KeyType, key.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{key.Curve, key.KeyType, key.X}
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// CanSign returns true if the key set has signing key.
+func (ks *KeySet) CanSign() bool {
+	return ks != nil && ks.signingKey != nil
+}
+
+// Sign to sign jwt claims with the signing key.
+// Key id will be put in the token header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if !ks.CanSign() {
+		return "", ErrNoSigningKey
+	}
+
+	key := ks.keys[ks.signingKID]
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.KeyID
+
+	return token.SignedString(ks.signingKey)
+}
+
+// Keyfunc to get verification key by the token
+// header key id. Can be used in jwt.Parse.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	if ks == nil {
+		return nil, ErrUnknownKeyID
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, ErrInvalidAlgorithm
+	}
+
+	return key.publicKey, nil
+}
+
+// JWKS to get all verification keys as JSON Web Key Set.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []Key{}}
+	if ks == nil {
+		return jwks
+	}
+
+	for _, kid := range ks.kids {
+		jwks.Keys = append(jwks.Keys, *ks.keys[kid])
+	}
+
+	return jwks
+}
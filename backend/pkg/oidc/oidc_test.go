@@ -0,0 +1,258 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/rl404/image-randomizer/pkg/oidc"
+	"github.com/rl404/image-randomizer/pkg/oidc/oidctest"
+)
+
+const (
+	clientID    = "client"
+	redirectURL = "http://localhost/oidc/callback"
+)
+
+func newProvider(t *testing.T) (*oidctest.Provider, *oidc.Provider) {
+	t.Helper()
+
+	mock, err := oidctest.NewProvider(clientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mock.Close)
+
+	return mock, oidc.New(oidc.Config{
+		Issuer:      mock.Issuer(),
+		ClientID:    clientID,
+		RedirectURL: redirectURL,
+		Scopes:      []string{"openid", "email"},
+	}, nil)
+}
+
+// login to run the flow until id token is received.
+func login(t *testing.T, mock *oidctest.Provider, p *oidc.Provider, nonce string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	ctx := context.Background()
+
+	authURL, err := p.AuthCodeURL(ctx, "state", nonce, "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := mock.Authorize(authURL, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idToken, err := p.Exchange(ctx, code, "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return idToken
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	mock, p := newProvider(t)
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := u.Scheme+"://"+u.Host+u.Path, mock.Issuer()+"/authorize"; got != want {
+		t.Errorf("endpoint = %q, want %q", got, want)
+	}
+
+	q := u.Query()
+	for k, want := range map[string]string{
+		"response_type":         "code",
+		"client_id":             clientID,
+		"redirect_uri":          redirectURL,
+		"scope":                 "openid email",
+		"state":                 "state",
+		"nonce":                 "nonce",
+		"code_challenge":        oidc.CodeChallenge("verifier"),
+		"code_challenge_method": "S256",
+	} {
+		if got := q.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestDiscoveryInvalidIssuer(t *testing.T) {
+	mock, _ := newProvider(t)
+
+	// Discovery is served for any issuer path
+	// but reports the real issuer.
+	p := oidc.New(oidc.Config{
+		Issuer:   mock.Issuer() + "/other",
+		ClientID: clientID,
+	}, nil)
+
+	if _, err := p.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); !errors.Is(err, oidc.ErrInvalidIssuer) {
+		t.Errorf("err = %v, want %v", err, oidc.ErrInvalidIssuer)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("valid code verifier", func(t *testing.T) {
+		mock, p := newProvider(t)
+
+		claims, err := p.Verify(ctx, login(t, mock, p, "nonce", nil), "nonce")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if claims.Issuer != mock.Issuer() || claims.Subject != "subject" || claims.Email != "user@example.com" {
+			t.Errorf("unexpected claims %+v", claims)
+		}
+	})
+
+	t.Run("invalid code verifier", func(t *testing.T) {
+		mock, p := newProvider(t)
+
+		authURL, err := p.AuthCodeURL(ctx, "state", "nonce", "verifier")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		code, err := mock.Authorize(authURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := p.Exchange(ctx, code, "other-verifier"); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("reused code", func(t *testing.T) {
+		mock, p := newProvider(t)
+
+		authURL, err := p.AuthCodeURL(ctx, "state", "nonce", "verifier")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		code, err := mock.Authorize(authURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := p.Exchange(ctx, code, "verifier"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := p.Exchange(ctx, code, "verifier"); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		nonce  string
+		err    error
+	}{
+		{
+			name:  "valid",
+			nonce: "nonce",
+		},
+		{
+			name:  "invalid nonce",
+			nonce: "other-nonce",
+			err:   oidc.ErrInvalidNonce,
+		},
+		{
+			name:   "invalid issuer",
+			claims: jwt.MapClaims{"iss": "http://other-issuer"},
+			nonce:  "nonce",
+			err:    oidc.ErrInvalidIssuer,
+		},
+		{
+			name:   "invalid audience",
+			claims: jwt.MapClaims{"aud": "other-client"},
+			nonce:  "nonce",
+			err:    oidc.ErrInvalidAudience,
+		},
+		{
+			name:   "multiple audience with other authorized party",
+			claims: jwt.MapClaims{"aud": []string{clientID, "other-client"}, "azp": "other-client"},
+			nonce:  "nonce",
+			err:    oidc.ErrInvalidAudience,
+		},
+		{
+			name:   "multiple audience",
+			claims: jwt.MapClaims{"aud": []string{clientID, "other-client"}, "azp": clientID},
+			nonce:  "nonce",
+		},
+		{
+			name:   "missing subject",
+			claims: jwt.MapClaims{"sub": nil},
+			nonce:  "nonce",
+			err:    oidc.ErrMissingSubject,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, p := newProvider(t)
+
+			_, err := p.Verify(context.Background(), login(t, mock, p, "nonce", tt.claims), tt.nonce)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		mock, p := newProvider(t)
+
+		idToken := login(t, mock, p, "nonce", jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})
+		if _, err := p.Verify(context.Background(), idToken, "nonce"); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("signed by other key", func(t *testing.T) {
+		mock, p := newProvider(t)
+		other, _ := newProvider(t)
+
+		// Make sure keys are already fetched.
+		if _, err := p.Verify(context.Background(), login(t, mock, p, "nonce", nil), "nonce"); err != nil {
+			t.Fatal(err)
+		}
+
+		idToken, err := other.Sign(jwt.MapClaims{
+			"iss":   mock.Issuer(),
+			"aud":   clientID,
+			"sub":   "subject",
+			"nonce": "nonce",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := p.Verify(context.Background(), idToken, "nonce"); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
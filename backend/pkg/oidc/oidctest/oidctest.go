@@ -0,0 +1,235 @@
+// Package oidctest provides mock oidc provider
+// for testing oidc relying party.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const keyID = "test-key"
+
+// Errors.
+var (
+	ErrInvalidClient      = errors.New("invalid client id")
+	ErrInvalidRedirectURL = errors.New("invalid redirect url")
+	ErrInvalidChallenge   = errors.New("invalid code challenge")
+)
+
+type authRequest struct {
+	redirectURL   string
+	nonce         string
+	codeChallenge string
+	claims        jwt.MapClaims
+}
+
+// Provider is mock oidc provider. It serves discovery,
+// jwks and token endpoint with PKCE verification.
+type Provider struct {
+	Server   *httptest.Server
+	ClientID string
+
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authRequest
+}
+
+// NewProvider to create and start new mock oidc provider.
+func NewProvider(clientID string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provider{
+		ClientID: clientID,
+		key:      key,
+		codes:    make(map[string]authRequest),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /", p.handleDiscovery)
+	mux.HandleFunc("GET /jwks", p.handleJWKS)
+	mux.HandleFunc("POST /token", p.handleToken)
+
+	p.Server = httptest.NewServer(mux)
+
+	return p, nil
+}
+
+// Issuer to get provider issuer url.
+func (p *Provider) Issuer() string {
+	return p.Server.URL
+}
+
+// Close to stop the provider.
+func (p *Provider) Close() {
+	p.Server.Close()
+}
+
+// Authorize to simulate user login at the authorization url.
+// Returns authorization code to be exchanged. Claims will
+// override the default id token claims, nil value
+// removes the claim.
+func (p *Provider) Authorize(authURL string, claims jwt.MapClaims) (string, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+
+	if q.Get("client_id") != p.ClientID {
+		return "", ErrInvalidClient
+	}
+
+	if q.Get("redirect_uri") == "" {
+		return "", ErrInvalidRedirectURL
+	}
+
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		return "", ErrInvalidChallenge
+	}
+
+	code := rand.Text()
+
+	p.mu.Lock()
+	p.codes[code] = authRequest{
+		redirectURL:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		claims:        claims,
+	}
+	p.mu.Unlock()
+
+	return code, nil
+}
+
+// Sign to sign id token claims with provider key.
+func (p *Provider) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	return token.SignedString(p.key)
+}
+
+// handleDiscovery serves discovery under any issuer
+// path but always reports the real issuer.
+func (p *Provider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/.well-known/openid-configuration") {
+		http.NotFound(w, r)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.Issuer(),
+		"authorization_endpoint": p.Issuer() + "/authorize",
+		"token_endpoint":         p.Issuer() + "/token",
+		"jwks_uri":               p.Issuer() + "/jwks",
+	})
+}
+
+func (p *Provider) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, "invalid_request")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError(w, "unsupported_grant_type")
+		return
+	}
+
+	if r.PostForm.Get("client_id") != p.ClientID {
+		writeError(w, "invalid_client")
+		return
+	}
+
+	// Code can only be used once.
+	code := r.PostForm.Get("code")
+
+	p.mu.Lock()
+	req, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	if !ok || req.redirectURL != r.PostForm.Get("redirect_uri") {
+		writeError(w, "invalid_grant")
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != req.codeChallenge {
+		writeError(w, "invalid_grant")
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   p.Issuer(),
+		"aud":   p.ClientID,
+		"sub":   "subject",
+		"email": "user@example.com",
+		"nonce": req.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	}
+
+	for k, v := range req.claims {
+		if v == nil {
+			delete(claims, k)
+			continue
+		}
+		claims[k] = v
+	}
+
+	idToken, err := p.Sign(claims)
+	if err != nil {
+		writeError(w, "server_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func writeError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": strings.ReplaceAll(code, "_", " "),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(data)
+}
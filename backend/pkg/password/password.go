@@ -0,0 +1,115 @@
+// Package password hashes and verifies password
+// using argon2id.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Config is argon2id and pepper config.
+type Config struct {
+	Pepper       string
+	LegacyPepper string
+	Memory       uint32
+	Iterations   uint32
+	Parallelism  uint8
+}
+
+// Hasher is password hasher.
+type Hasher struct {
+	cfg     Config
+	saltLen int
+	keyLen  uint32
+}
+
+// New to create new password hasher.
+func New(cfg Config) *Hasher {
+	return &Hasher{
+		cfg:     cfg,
+		saltLen: 16,
+		keyLen:  32,
+	}
+}
+
+const argon2idPrefix = "$argon2id$"
+
+// Hash to hash password using argon2id. The parameters
+// and salt are encoded in the returned hash.
+func (h *Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password+h.cfg.Pepper), salt, h.cfg.Iterations, h.cfg.Memory, h.cfg.Parallelism, h.keyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.cfg.Memory,
+		h.cfg.Iterations,
+		h.cfg.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify to verify password with the hash using
+// constant-time comparison. Legacy SHA-1 hash with salt
+// is still supported. Rehash will be true if the hash
+// should be replaced with the current algorithm and parameters.
+func (h *Hasher) Verify(password, hash, salt string) (ok bool, rehash bool) {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return h.verifyLegacy(password, hash, salt), true
+	}
+
+	var version int
+	var memory, iterations uint32
+	var parallelism uint8
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return false, false
+	}
+
+	saltBytes, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false
+	}
+
+	otherKey := argon2.IDKey([]byte(password+h.cfg.Pepper), saltBytes, iterations, memory, parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return false, false
+	}
+
+	rehash = memory != h.cfg.Memory || iterations != h.cfg.Iterations || parallelism != h.cfg.Parallelism
+
+	return true, rehash
+}
+
+func (h *Hasher) verifyLegacy(password, hash, salt string) bool {
+	hasher := sha1.New()
+	hasher.Write([]byte(salt + password + h.cfg.LegacyPepper))
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hex.EncodeToString(hasher.Sum(nil)))) == 1
+}
@@ -0,0 +1,154 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Low cost parameters to keep the test fast.
+var testConfig = Config{
+	Pepper:       "pepper",
+	LegacyPepper: "legacy-pepper",
+	Memory:       1024,
+	Iterations:   1,
+	Parallelism:  1,
+}
+
+func legacyHash(salt, password, pepper string) string {
+	h := sha1.New()
+	h.Write([]byte(salt + password + pepper))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func TestHashVerify(t *testing.T) {
+	h := New(testConfig)
+
+	hash, err := h.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		t.Fatalf("hash = %s, want prefix %s", hash, argon2idPrefix)
+	}
+
+	otherHash, err := h.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash == otherHash {
+		t.Error("same password should have different salt")
+	}
+
+	stronger := testConfig
+	stronger.Iterations = 2
+
+	otherPepper := testConfig
+	otherPepper.Pepper = "other-pepper"
+
+	tests := []struct {
+		name       string
+		hasher     *Hasher
+		password   string
+		hash       string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{
+			name:     "round-trip",
+			hasher:   h,
+			password: "password",
+			hash:     hash,
+			wantOK:   true,
+		},
+		{
+			name:     "wrong password",
+			hasher:   h,
+			password: "wrong-password",
+			hash:     hash,
+		},
+		{
+			name:     "empty password",
+			hasher:   h,
+			password: "",
+			hash:     hash,
+		},
+		{
+			name:     "different pepper",
+			hasher:   New(otherPepper),
+			password: "password",
+			hash:     hash,
+		},
+		{
+			name:       "outdated parameters",
+			hasher:     New(stronger),
+			password:   "password",
+			hash:       hash,
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{
+			name:     "malformed hash",
+			hasher:   h,
+			password: "password",
+			hash:     argon2idPrefix + "v=19$m=1024,t=1,p=1$invalid",
+		},
+		{
+			name:     "unknown version",
+			hasher:   h,
+			password: "password",
+			hash:     strings.Replace(hash, "v=19", "v=16", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash := tt.hasher.Verify(tt.password, tt.hash, "")
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Errorf("Verify() = %v, %v, want %v, %v", ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestVerifyLegacy(t *testing.T) {
+	h := New(testConfig)
+	hash := legacyHash("salt", "password", testConfig.LegacyPepper)
+
+	tests := []struct {
+		name     string
+		password string
+		salt     string
+		wantOK   bool
+	}{
+		{
+			name:     "correct password",
+			password: "password",
+			salt:     "salt",
+			wantOK:   true,
+		},
+		{
+			name:     "wrong password",
+			password: "wrong-password",
+			salt:     "salt",
+		},
+		{
+			name:     "wrong salt",
+			password: "password",
+			salt:     "other-salt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Legacy hash should always be upgraded.
+			ok, rehash := h.Verify(tt.password, hash, tt.salt)
+			if ok != tt.wantOK || !rehash {
+				t.Errorf("Verify() = %v, %v, want %v, true", ok, rehash, tt.wantOK)
+			}
+		})
+	}
+}
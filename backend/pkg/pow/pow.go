@@ -0,0 +1,144 @@
+// Package pow implements stateless hashcash-style proof-of-work.
+//
+// A challenge is signed with HMAC so the server doesn't need to
+// store it. The client should find a nonce so that
+// sha256(challenge + nonce) has at least difficulty leading
+// zero bits.
+package pow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/bits"
+	"strings"
+	"time"
+)
+
+// Errors.
+var (
+	ErrInvalidChallenge = errors.New("invalid challenge")
+	ErrExpiredChallenge = errors.New("challenge already expired")
+	ErrInvalidNonce     = errors.New("invalid nonce")
+)
+
+// Challenge is proof-of-work challenge.
+type Challenge struct {
+	ID         string
+	Challenge  string
+	Difficulty int
+	ExpiredAt  time.Time
+}
+
+type payload struct {
+	ID         string `json:"id"`
+	Difficulty int    `json:"d"`
+	ExpiredAt  int64  `json:"exp"`
+}
+
+// POW is proof-of-work challenge issuer and verifier.
+type POW struct {
+	secret []byte
+}
+
+// New to create new proof-of-work.
+func New(secret string) *POW {
+	return &POW{
+		secret: []byte(secret),
+	}
+}
+
+// Create to create new signed challenge.
+func (p *POW) Create(difficulty int, expired time.Duration) (*Challenge, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	pl := payload{
+		ID:         base64.RawURLEncoding.EncodeToString(id),
+		Difficulty: difficulty,
+		ExpiredAt:  time.Now().Add(expired).Unix(),
+	}
+
+	data, err := json.Marshal(pl)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+
+	return &Challenge{
+		ID:         pl.ID,
+		Challenge:  encoded + "." + p.sign(encoded),
+		Difficulty: pl.Difficulty,
+		ExpiredAt:  time.Unix(pl.ExpiredAt, 0),
+	}, nil
+}
+
+// Verify to verify challenge signature, expiration,
+// and the nonce solution.
+func (p *POW) Verify(challenge, nonce string) (*Challenge, error) {
+	encoded, signature, ok := strings.Cut(challenge, ".")
+	if !ok {
+		return nil, ErrInvalidChallenge
+	}
+
+	if subtle.ConstantTimeCompare([]byte(p.sign(encoded)), []byte(signature)) != 1 {
+		return nil, ErrInvalidChallenge
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	var pl payload
+	if err := json.Unmarshal(data, &pl); err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	expiredAt := time.Unix(pl.ExpiredAt, 0)
+	if time.Now().After(expiredAt) {
+		return nil, ErrExpiredChallenge
+	}
+
+	if LeadingZeroBits(Hash(challenge, nonce)) < pl.Difficulty {
+		return nil, ErrInvalidNonce
+	}
+
+	return &Challenge{
+		ID:         pl.ID,
+		Challenge:  challenge,
+		Difficulty: pl.Difficulty,
+		ExpiredAt:  expiredAt,
+	}, nil
+}
+
+// Hash to hash challenge and nonce.
+func Hash(challenge, nonce string) []byte {
+	sum := sha256.Sum256([]byte(challenge + nonce))
+	return sum[:]
+}
+
+// LeadingZeroBits to count leading zero bits of the hash.
+func LeadingZeroBits(hash []byte) int {
+	var n int
+	for _, b := range hash {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+func (p *POW) sign(data string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
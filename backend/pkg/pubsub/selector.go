@@ -0,0 +1,33 @@
+package pubsub
+
+import (
+	"errors"
+
+	"github.com/rl404/fairy/pubsub"
+	"github.com/rl404/fairy/pubsub/nop"
+	"github.com/rl404/fairy/pubsub/redis"
+)
+
+// PubsubType is type for pubsub.
+type PubsubType int8
+
+// Available types for pubsub.
+const (
+	NOP PubsubType = iota
+	Redis
+)
+
+// ErrInvalidPubsubType is error for invalid pubsub type.
+var ErrInvalidPubsubType = errors.New("invalid pubsub type")
+
+// New to create new pubsub client depends on the type.
+func New(pubsubType PubsubType, address string, password string) (pubsub.PubSub, error) {
+	switch pubsubType {
+	case NOP:
+		return nop.New(), nil
+	case Redis:
+		return redis.New(address, password)
+	default:
+		return nil, ErrInvalidPubsubType
+	}
+}
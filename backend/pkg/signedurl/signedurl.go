@@ -0,0 +1,88 @@
+// Package signedurl signs url path with HMAC so it can be
+// accessed without session until it is expired.
+//
+// The signature covers the path, scope and expiration so a
+// signed url can't be reused for other resource or purpose.
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Query keys added to signed url.
+const (
+	QueryExpires   = "expires"
+	QuerySignature = "signature"
+)
+
+// Errors.
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpired          = errors.New("url already expired")
+)
+
+// Signer signs and verifies url.
+type Signer struct {
+	secret []byte
+}
+
+// New to create new url signer.
+func New(secret string) *Signer {
+	return &Signer{
+		secret: []byte(secret),
+	}
+}
+
+// Sign to sign path for the scope and return the signed url.
+// Zero expiredAt means the url never expires.
+func (s *Signer) Sign(path, scope string, expiredAt time.Time) string {
+	var expires string
+	if !expiredAt.IsZero() {
+		expires = strconv.FormatInt(expiredAt.Unix(), 10)
+	}
+
+	q := url.Values{}
+	if expires != "" {
+		q.Set(QueryExpires, expires)
+	}
+	q.Set(QuerySignature, s.sign(path, scope, expires))
+
+	return path + "?" + q.Encode()
+}
+
+// Verify to verify signed url query of the path for the scope.
+func (s *Signer) Verify(path, scope string, query url.Values) error {
+	expires := query.Get(QueryExpires)
+	signature := query.Get(QuerySignature)
+
+	if signature == "" || !hmac.Equal([]byte(s.sign(path, scope, expires)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	if expires == "" {
+		return nil
+	}
+
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if time.Now().Unix() > exp {
+		return ErrExpired
+	}
+
+	return nil
+}
+
+func (s *Signer) sign(path, scope, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(path + "\n" + scope + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,124 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// RFC 6238 test secret "12345678901234567890".
+const testSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerate(t *testing.T) {
+	// Last 6 digits of RFC 6238 SHA-1 test vectors.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := Generate(testSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != tt.want {
+			t.Errorf("Generate(%d) = %s, want %s", tt.unix, code, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	codeAt := func(d time.Duration) string {
+		code, err := Generate(testSecret, now.Add(d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{
+			name:     "current step",
+			secret:   testSecret,
+			code:     codeAt(0),
+			skew:     1,
+			wantStep: Step(now),
+			wantOK:   true,
+		},
+		{
+			name:     "previous step within skew",
+			secret:   testSecret,
+			code:     codeAt(-Period * time.Second),
+			skew:     1,
+			wantStep: Step(now) - 1,
+			wantOK:   true,
+		},
+		{
+			name:     "next step within skew",
+			secret:   testSecret,
+			code:     codeAt(Period * time.Second),
+			skew:     1,
+			wantStep: Step(now) + 1,
+			wantOK:   true,
+		},
+		{
+			name:   "previous step without skew",
+			secret: testSecret,
+			code:   codeAt(-Period * time.Second),
+		},
+		{
+			name:   "outside skew",
+			secret: testSecret,
+			code:   codeAt(-2 * Period * time.Second),
+			skew:   1,
+		},
+		{
+			name:   "wrong code",
+			secret: testSecret,
+			code:   "000000",
+			skew:   1,
+		},
+		{
+			name:   "wrong length",
+			secret: testSecret,
+			code:   codeAt(0)[:Digits-1],
+			skew:   1,
+		},
+		{
+			name:     "lowercase secret",
+			secret:   " gezdgnbvgy3tqojqgezdgnbvgy3tqojq ",
+			code:     codeAt(0),
+			wantStep: Step(now),
+			wantOK:   true,
+		},
+		{
+			name:   "invalid secret",
+			secret: "not base32!",
+			code:   codeAt(0),
+			skew:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(tt.secret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate() = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
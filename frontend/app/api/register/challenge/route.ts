@@ -0,0 +1,19 @@
+export type Data = {
+  status: number;
+  message: string;
+  data: Challenge;
+};
+
+export type Challenge = {
+  challenge: string;
+  difficulty: number;
+  expired_at: string;
+};
+
+export async function GET() {
+  const resp = await fetch(`${process.env.NEXT_PUBLIC_API_HOST}/register/challenge`, {
+    cache: 'no-store',
+  });
+  const data = await resp.json();
+  return Response.json(data, { status: resp.status });
+}
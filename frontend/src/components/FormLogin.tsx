@@ -1,6 +1,8 @@
 'use client';
 
+import { Challenge } from '@/app/api/register/challenge/route';
 import { Token } from '@/app/api/token/refresh/route';
+import { solveChallenge } from '@/src/utils/pow';
 import { saveAccessToken, saveRefreshToken, saveUsername } from '@/src/utils/storage';
 import KeyIcon from '@mui/icons-material/Key';
 import PersonIcon from '@mui/icons-material/Person';
@@ -80,12 +82,27 @@ export default function FormLogin() {
       });
   };
 
+  const getChallenge = async (): Promise<{ challenge: string; nonce: string }> => {
+    try {
+      const resp = await axios.get('/api/register/challenge');
+      const data: Challenge = resp.data.data;
+      return { challenge: data.challenge, nonce: await solveChallenge(data.challenge, data.difficulty) };
+    } catch (error) {
+      // Challenge is disabled.
+      if (axios.isAxiosError(error) && error.response?.status === 404) return { challenge: '', nonce: '' };
+      throw error;
+    }
+  };
+
   const onRegister = async () => {
-    await axios
-      .post('/api/register', {
-        username: formState.username,
-        password: formState.password,
-      })
+    await getChallenge()
+      .then((challenge) =>
+        axios.post('/api/register', {
+          username: formState.username,
+          password: formState.password,
+          ...challenge,
+        }),
+      )
       .then((resp) => {
         const data: Token = resp.data.data;
         saveAccessToken(data.access_token);
@@ -0,0 +1,19 @@
+const leadingZeroBits = (hash: Uint8Array): number => {
+  let n = 0;
+  for (const b of hash) {
+    if (b !== 0) return n + Math.clz32(b) - 24;
+    n += 8;
+  }
+  return n;
+};
+
+// Find nonce so that sha256(challenge + nonce)
+// has at least difficulty leading zero bits.
+export const solveChallenge = async (challenge: string, difficulty: number): Promise<string> => {
+  const encoder = new TextEncoder();
+  for (let i = 0; ; i++) {
+    const nonce = i.toString();
+    const hash = await crypto.subtle.digest('SHA-256', encoder.encode(challenge + nonce));
+    if (leadingZeroBits(new Uint8Array(hash)) >= difficulty) return nonce;
+  }
+};